@@ -0,0 +1,233 @@
+// Encode and decode Binary WSV (BWSV), a compact binary representation of a WSV document.
+//
+// A BWSV document starts with the preamble "BW1" followed by a sequence of VarInt56 encoded codes:
+//
+//	0     line break
+//	1     null value
+//	n + 2 string value of n UTF-8 bytes, which follow the code
+//
+// Comments are not part of the binary format and are dropped when encoding.
+package bwsv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+var (
+	ErrInvalidPreamble = errors.New("data does not start with the BWSV preamble")
+	ErrInvalidVarInt   = errors.New("invalid VarInt56")
+	ErrInvalidString   = errors.New("string value is not valid UTF-8")
+	ErrValueTooLarge   = errors.New("value too large for VarInt56")
+)
+
+var Preamble = []byte("BW1")
+
+const (
+	codeLineBreak = 0
+	codeNull      = 1
+	// string values are encoded as their length in bytes plus the offset
+	codeStringOffset = 2
+)
+
+// Encode returns the binary encoding of the document's lines, comments are dropped. A value longer than VarInt56 can
+// store returns ErrValueTooLarge
+func Encode(doc *document.Document) ([]byte, error) {
+	buf := bytes.Clone(Preamble)
+	var err error
+	for i, line := range doc.Lines() {
+		if i > 0 {
+			buf, _ = appendVarInt56(buf, codeLineBreak)
+		}
+		for _, field := range line.Fields() {
+			if field.IsNull {
+				buf, _ = appendVarInt56(buf, codeNull)
+				continue
+			}
+			buf, err = appendVarInt56(buf, uint64(len(field.Value))+codeStringOffset)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, field.Value...)
+		}
+	}
+	return buf, nil
+}
+
+// Decode returns the document encoded in b. The document is tabular when every non-empty line has the same number of values,
+// the first line is used as the header line.
+func Decode(b []byte) (*document.Document, error) {
+	dec := NewDecoder(bytes.NewReader(b))
+	lines := make([]reader.ReaderLine, 0)
+	for {
+		line, err := dec.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	doc := document.NewDocument()
+	fieldCount := -1
+	for _, line := range lines {
+		if line.FieldCount() == 0 {
+			continue
+		}
+		if fieldCount != -1 && fieldCount != line.FieldCount() {
+			doc.Tabular = false
+			break
+		}
+		fieldCount = line.FieldCount()
+	}
+	for _, line := range lines {
+		docLine, err := doc.AddLine()
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range line.Fields() {
+			if field.IsNull {
+				err = docLine.AppendNull()
+			} else {
+				err = docLine.Append(field.Value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+// A Decoder reads the lines of a BWSV document one at a time
+type Decoder struct {
+	r *bufio.Reader
+	// Treat the first line as the header line, field names of the following lines are taken from it
+	IncludesHeader bool
+	headers        []string
+	numLine        int
+	started        bool
+	ended          bool
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:              bufio.NewReader(r),
+		IncludesHeader: true,
+	}
+}
+
+func (dec *Decoder) Headers() []string {
+	return dec.headers
+}
+
+// Read decodes the next line. If there is no data left to be read, Read returns nil, io.EOF
+func (dec *Decoder) Read() (reader.ReaderLine, error) {
+	if dec.ended {
+		return nil, io.EOF
+	}
+	if !dec.started {
+		dec.started = true
+		preamble := make([]byte, len(Preamble))
+		_, err := io.ReadFull(dec.r, preamble)
+		if err != nil || !bytes.Equal(preamble, Preamble) {
+			return nil, ErrInvalidPreamble
+		}
+		// a document without any codes has no lines
+		if _, err := dec.r.Peek(1); err == io.EOF {
+			dec.ended = true
+			return nil, io.EOF
+		}
+	}
+
+	dec.numLine++
+	isHeader := dec.IncludesHeader && dec.numLine == 1
+	fields := make([]record.RecordField, 0)
+	for {
+		code, err := readVarInt56(dec.r)
+		if err == io.EOF {
+			dec.ended = true
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if code == codeLineBreak {
+			break
+		}
+		field := record.RecordField{FieldIndex: len(fields), RowIndex: dec.numLine, IsHeader: isHeader}
+		if code == codeNull {
+			field.IsNull = true
+		} else {
+			// the length is not trusted for an allocation, the buffer only grows with the bytes actually read
+			n := int64(code - codeStringOffset)
+			var b bytes.Buffer
+			if _, err := io.CopyN(&b, dec.r, n); err != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if !utf8.Valid(b.Bytes()) {
+				return nil, ErrInvalidString
+			}
+			field.Value = b.String()
+		}
+		if isHeader {
+			field.FieldName = field.Value
+			dec.headers = append(dec.headers, field.Value)
+		} else if field.FieldIndex < len(dec.headers) {
+			field.FieldName = dec.headers[field.FieldIndex]
+		}
+		fields = append(fields, field)
+	}
+	return reader.NewLine(dec.numLine, fields, "", isHeader), nil
+}
+
+// VarInt56 stores up to 56 bits in 1 to 8 bytes, the number of leading one bits of the first byte
+// is the number of bytes following it, the value is stored big endian in the remaining bits.
+func appendVarInt56(buf []byte, v uint64) ([]byte, error) {
+	for n := 0; n < 8; n++ {
+		// bits available with n following bytes
+		bits := 7 - n + 8*n
+		if v >= 1<<bits {
+			continue
+		}
+		prefix := byte(0xFF << (8 - n))
+		buf = append(buf, prefix|byte(v>>(8*n)))
+		for i := n - 1; i >= 0; i-- {
+			buf = append(buf, byte(v>>(8*i)))
+		}
+		return buf, nil
+	}
+	return buf, ErrValueTooLarge
+}
+
+func readVarInt56(r *bufio.Reader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for n < 8 && first&(0x80>>n) != 0 {
+		n++
+	}
+	if n == 8 {
+		return 0, ErrInvalidVarInt
+	}
+	v := uint64(first & (0xFF >> (n + 1)))
+	for range n {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
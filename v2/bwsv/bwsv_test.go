@@ -0,0 +1,132 @@
+package bwsv_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/bwsv"
+	"github.com/internetcalifornia/wsv/v2/document"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	long := strings.Repeat("ab", 10_000)
+	doc := document.NewDocument()
+	doc.AppendLine(document.Fields("Country", "Capital", "Facts")...)
+	doc.AppendLine(document.Field("Japan"), document.Field("Tokyo"), document.Field("yen ¥\n火山"))
+	doc.AppendLine(document.Field("India"), document.Field(""), document.Null())
+	doc.AddLine()
+	doc.AppendLine(document.Field("Long"), document.Field(long), document.Field("-"))
+
+	b, err := bwsv.Encode(doc)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.HasPrefix(b, bwsv.Preamble) {
+		t.Error("expected the output to start with the preamble")
+	}
+	decoded, err := bwsv.Decode(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, err := decoded.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(exp) != string(out) {
+		t.Errorf("expected the decoded document\n%s\nbut got\n%s", exp, out)
+	}
+	line, err := decoded.Line(3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if f, _ := line.Field(1); f.IsNull || f.Value != "" {
+		t.Errorf("expected an empty string but got %+v", f)
+	}
+	if f, _ := line.Field(2); !f.IsNull {
+		t.Errorf("expected a null but got %+v", f)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	doc := document.NewDocument()
+	doc.Tabular = false
+	doc.AppendLine(document.Fields("Name", "Age")...)
+	doc.AppendLine(document.Field("Scott"), document.Null())
+	doc.AppendLine(document.Fields("Jane", "21", "extra")...)
+
+	b, err := bwsv.Encode(doc)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	dec := bwsv.NewDecoder(bytes.NewReader(b))
+	header, err := dec.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !header.IsHeaderLine() || header.FieldCount() != 2 {
+		t.Errorf("expected a header line with 2 fields but got %d", header.FieldCount())
+	}
+	line, err := dec.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if f, err := line.Field(1); err != nil || !f.IsNull || f.FieldName != "Age" {
+		t.Errorf("expected Age to be null but got %+v", f)
+	}
+	line, err = dec.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if line.FieldCount() != 3 || line.LineNumber() != 3 {
+		t.Errorf("expected line 3 to have 3 fields but got line %d with %d", line.LineNumber(), line.FieldCount())
+	}
+	_, err = dec.Read()
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+
+	decoded, err := bwsv.Decode(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if decoded.Tabular {
+		t.Error("expected lines with a different number of values to decode as a non-tabular document")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := bwsv.Decode([]byte("WSV"))
+	if err != bwsv.ErrInvalidPreamble {
+		t.Errorf("expected an invalid preamble but got %v", err)
+	}
+	_, err = bwsv.Decode(append(bytes.Clone(bwsv.Preamble), 0x10, 'a'))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected an unexpected EOF but got %v", err)
+	}
+	// the largest length VarInt56 can declare, without the bytes it declares
+	oversized := append(bytes.Clone(bwsv.Preamble), 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 'a')
+	_, err = bwsv.Decode(oversized)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected an unexpected EOF for an oversized length but got %v", err)
+	}
+	doc, err := bwsv.Decode(bwsv.Preamble)
+	if err != nil || doc.LineCount() != 0 {
+		t.Errorf("expected an empty document but got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/internetcalifornia/wsv/v2/convert"
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+func runConvert(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: wsv convert [flags] [file]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Converts between wsv, csv, tsv, json and ndjson, reads stdin when no file or - is given.")
+		fmt.Fprintln(stderr, "The formats default to the extensions of the files, or wsv.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	from := flags.String("from", "", "format of the input")
+	to := flags.String("to", "", "format of the output")
+	output := flags.String("o", "", "file to write the output to instead of stdout")
+	null := flags.String("null", "", "value of a null in csv and tsv cells, empty cells are null by default")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "wsv convert: only one file can be converted at a time")
+		return exitError
+	}
+	file := flags.Arg(0)
+	if file == "" {
+		file = "-"
+	}
+
+	fromFormat, err := formatFlag(*from, file)
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv convert: %v\n", err)
+		return exitError
+	}
+	toFormat, err := formatFlag(*to, *output)
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv convert: %v\n", err)
+		return exitError
+	}
+	name, in, err := openInput(file, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv convert: %v\n", err)
+		return exitError
+	}
+	defer in.Close()
+
+	// the output file is only written once the conversion succeeded
+	var out io.Writer = stdout
+	var buf bytes.Buffer
+	if *output != "" && *output != "-" {
+		out = &buf
+	}
+	opts := convert.Options{NullSentinel: *null}
+	if fromFormat == convert.WSV {
+		// WSV input is converted one line at a time
+		err = convert.EncodeReader(out, reader.NewReader(in), toFormat, opts)
+	} else {
+		doc, decodeErr := convert.Decode(in, fromFormat, opts)
+		err = decodeErr
+		if err == nil {
+			err = convert.Encode(out, doc, toFormat, opts)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv convert: %s: %v\n", name, err)
+		return exitError
+	}
+	if out == &buf {
+		if err := os.WriteFile(*output, buf.Bytes(), 0o644); err != nil {
+			fmt.Fprintf(stderr, "wsv convert: %v\n", err)
+			return exitError
+		}
+	}
+	return exitOK
+}
+
+// opens the file, or stdin for "-", and returns the name to report it by
+func openInput(file string, stdin io.Reader) (string, io.ReadCloser, error) {
+	if file == "-" {
+		return stdinName, io.NopCloser(stdin), nil
+	}
+	f, err := os.Open(file)
+	return file, f, err
+}
+
+// returns the format named by the flag, or the format of the file's extension, or WSV for stdin and stdout
+func formatFlag(name string, file string) (convert.Format, error) {
+	if name != "" {
+		return convert.ParseFormat(name)
+	}
+	if file == "" || file == "-" {
+		return convert.WSV, nil
+	}
+	return convert.FormatOf(file)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "partners.csv")
+	if err := os.WriteFile(in, []byte("Name,Age\nScott,NULL\n\"Jane Doe\",21\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "partners.wsv")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"convert", "--null", "NULL", "-o", out, in}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+		return
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "Name        Age\nScott       -\n\"Jane Doe\"  21\n"; string(data) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, data)
+	}
+
+	code = run([]string{"convert", "--to", "ndjson"}, bytes.NewReader(data), &stdout, &stderr)
+	if exp := "{\"Name\":\"Scott\",\"Age\":null}\n{\"Name\":\"Jane Doe\",\"Age\":\"21\"}\n"; code != exitOK || stdout.String() != exp {
+		t.Errorf("expected\n%s\nbut got %d\n%s", exp, code, stdout.String())
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"convert", "--to", "xml"}, strings.NewReader(""), &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "unknown format") {
+		t.Errorf("expected an unknown format but got %d %q", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"convert", "--from", "json"}, strings.NewReader("{"), &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), stdinName) {
+		t.Errorf("expected invalid JSON to fail but got %d %q", code, stderr.String())
+	}
+	if code := run([]string{"convert", "a.csv", "b.csv"}, nil, &stdout, &stderr); code != exitError {
+		t.Errorf("expected more than one file to fail but got %d", code)
+	}
+}
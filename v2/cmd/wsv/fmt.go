@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+)
+
+const stdinName = "<stdin>"
+
+func runFmt(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: wsv fmt [flags] [files]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Aligns the columns of WSV files and writes them to stdout, reads stdin when no file or - is given.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	check := flags.Bool("check", false, "list the files that are not formatted and exit with 1 if there are any, without writing output")
+	write := flags.Bool("write", false, "write the result to the file instead of stdout")
+	padding := flags.String("padding", "  ", "whitespace between columns, escapes such as \\t are allowed")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	pad, err := strconv.Unquote(`"` + *padding + `"`)
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv fmt: invalid padding %q\n", *padding)
+		return exitError
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	code := exitOK
+	for _, file := range files {
+		if *write && file == "-" {
+			fmt.Fprintln(stderr, "wsv fmt: cannot use --write with stdin")
+			return exitError
+		}
+		name, data, err := readInput(file, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "wsv fmt: %v\n", err)
+			code = exitError
+			continue
+		}
+		out, err := format(data, []rune(pad))
+		if err != nil {
+			fmt.Fprintf(stderr, "wsv fmt: %s: %v\n", name, err)
+			code = exitError
+			continue
+		}
+		formatted := bytes.Equal(data, out)
+		switch {
+		case *check:
+			if !formatted {
+				fmt.Fprintln(stdout, name)
+				code = max(code, exitFailure)
+			}
+		case *write:
+			if formatted {
+				continue
+			}
+			if err := writeFile(file, out); err != nil {
+				fmt.Fprintf(stderr, "wsv fmt: %v\n", err)
+				code = exitError
+			}
+		default:
+			stdout.Write(out)
+		}
+	}
+	return code
+}
+
+// reads the file, or stdin for "-", and returns the name to report it by
+func readInput(file string, stdin io.Reader) (string, []byte, error) {
+	if file == "-" {
+		data, err := io.ReadAll(stdin)
+		return stdinName, data, err
+	}
+	data, err := os.ReadFile(file)
+	return file, data, err
+}
+
+// writes the file keeping its permissions
+func writeFile(file string, data []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, info.Mode().Perm())
+}
+
+// format returns the data with aligned columns, the byte order mark and encoding of the data are kept.
+// Only whitespace changes: lines keep their order and short lines are not filled with nulls. The columns of data whose
+// lines have different numbers of values are not aligned, the values are only separated by the padding
+func format(data []byte, padding []rune) ([]byte, error) {
+	doc, err := readDocument(data, true)
+	if errors.Is(err, reader.ErrFieldCount) || errors.Is(err, document.ErrFieldCount) {
+		doc, err = readDocument(data, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the lines are aligned again and the schema comment is written as the line it was read from
+	for _, line := range doc.Lines() {
+		line.SetFormat(nil)
+	}
+	doc.SetSchema(nil)
+	if err := doc.SetPadding(padding); err != nil {
+		return nil, errors.New("padding must only contain whitespace")
+	}
+	if enc, n := reliabletxt.DetectEncoding(data); n > 0 {
+		if err := doc.SetEncoding(enc); err != nil {
+			return nil, err
+		}
+	}
+	return doc.WriteAll()
+}
+
+func readDocument(data []byte, tabular bool) (*document.Document, error) {
+	r := reader.NewReader(bytes.NewReader(data))
+	r.IsTabular = tabular
+	r.NullTrailingColumns = false
+	// keeps the schema comment as a line of the document
+	r.PreserveFormatting = true
+	return r.ToDocument()
+}
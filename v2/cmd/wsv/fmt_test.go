@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const unformatted = "# people\nName Age City\nScott 33 \"New York\"\n\nJane - Boston #moved\n"
+
+const formatted = "# people\nName   Age  City\nScott  33   \"New York\"\n\nJane   -    Boston  #moved\n"
+
+func TestFmtStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt"}, strings.NewReader(unformatted), &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != formatted {
+		t.Errorf("expected\n%s\nbut got\n%s", formatted, stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"fmt", "--padding", `\t`}, strings.NewReader("a b\nccc d\n"), &stdout, &stderr)
+	if exp := "a  \tb\nccc\td\n"; stdout.String() != exp {
+		t.Errorf("expected %q but got %q", exp, stdout.String())
+	}
+}
+
+func TestFmtCheck(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt", "--check"}, strings.NewReader(unformatted), &stdout, &stderr)
+	if code != exitFailure || stdout.String() != stdinName+"\n" {
+		t.Errorf("expected the unformatted input to be listed with exit code 1 but got %d %q", code, stdout.String())
+	}
+	stdout.Reset()
+	code = run([]string{"fmt", "--check"}, strings.NewReader(formatted), &stdout, &stderr)
+	if code != exitOK || stdout.Len() != 0 {
+		t.Errorf("expected the formatted input to pass but got %d %q", code, stdout.String())
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "people.wsv")
+	err := os.WriteFile(file, []byte(unformatted), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt", "--write", file}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != formatted {
+		t.Errorf("expected the file to be rewritten as\n%s\nbut got\n%s", formatted, data)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the permissions to be kept but got %s", info.Mode())
+	}
+	if code := run([]string{"fmt", "--write"}, strings.NewReader(""), &stdout, &stderr); code != exitError {
+		t.Errorf("expected writing stdin to fail but got %d", code)
+	}
+}
+
+func TestFmtErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt"}, strings.NewReader("Name Age\nScott \"33\n"), &stdout, &stderr)
+	if code != exitError || !strings.Contains(stderr.String(), stdinName) {
+		t.Errorf("expected a parse error but got %d %q", code, stderr.String())
+	}
+	stderr.Reset()
+	code = run([]string{"fmt", "--padding", "x"}, strings.NewReader("a b\n"), &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected an invalid padding to fail but got %d", code)
+	}
+	code = run([]string{"fmt", filepath.Join(t.TempDir(), "missing.wsv")}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected a missing file to fail but got %d", code)
+	}
+}
+
+func TestFmtKeepsSchemaLine(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt"}, strings.NewReader("#c1\n#@schema a:int\na b\n1 2\n"), &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+	}
+	if exp := "#c1\n#@schema a:int\na  b\n1  2\n"; stdout.String() != exp {
+		t.Errorf("expected the schema to stay on line 2 %q but got %q", exp, stdout.String())
+	}
+}
+
+func TestFmtKeepsShortLines(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt"}, strings.NewReader("a b c\nd\n"), &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+	}
+	if exp := "a  b  c\nd\n"; stdout.String() != exp {
+		t.Errorf("expected the short line not to be filled with nulls %q but got %q", exp, stdout.String())
+	}
+}
+
+func TestFmtNonTabular(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"fmt"}, strings.NewReader("a   b\nccc d  e\nf\n"), &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+	}
+	if exp := "a  b\nccc  d  e\nf\n"; stdout.String() != exp {
+		t.Errorf("expected the values to be separated by the padding %q but got %q", exp, stdout.String())
+	}
+}
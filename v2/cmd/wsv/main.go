@@ -0,0 +1,75 @@
+// Command wsv works with whitespace separated values files.
+//
+// Usage:
+//
+//	wsv <command> [flags] [files]
+//
+// The commands are:
+//
+//	fmt       align the columns of WSV files
+//	validate  report parse errors and schema violations of WSV files
+//	convert   convert between WSV, CSV, TSV, JSON and NDJSON
+//	query     select lines of a WSV file with SQL
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// exit codes of the commands
+const (
+	exitOK = 0
+	// the command ran but found a problem, such as an unformatted file
+	exitFailure = 1
+	// the command could not run, such as a usage or read error
+	exitError = 2
+)
+
+type command struct {
+	name  string
+	short string
+	run   func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = []command{
+	{"fmt", "align the columns of WSV files", runFmt},
+	{"validate", "report parse errors and schema violations of WSV files", runValidate},
+	{"convert", "convert between WSV, CSV, TSV, JSON and NDJSON", runConvert},
+	{"query", "select lines of a WSV file with SQL", runQuery},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitError
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "wsv: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitError
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: wsv <command> [flags] [files]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The commands are:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t%-10s%s\n", c.name, c.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Use \"wsv <command> -h\" for the flags of a command, files default to stdin.")
+}
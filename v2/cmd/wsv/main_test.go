@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, nil, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "Usage") {
+		t.Errorf("expected the usage with exit code 2 but got %d %q", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"lint"}, nil, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), `unknown command "lint"`) {
+		t.Errorf("expected an unknown command but got %d %q", code, stderr.String())
+	}
+	if code := run([]string{"help"}, nil, &stdout, &stderr); code != exitOK || !strings.Contains(stdout.String(), "fmt") {
+		t.Errorf("expected the commands to be listed but got %d %q", code, stdout.String())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/internetcalifornia/wsv/v2/convert"
+	"github.com/internetcalifornia/wsv/v2/query"
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+func runQuery(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: wsv query [flags] 'SELECT * | columns FROM file [WHERE condition] [ORDER BY columns] [LIMIT n]'")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Runs the query against the file named after FROM, use FROM - to read stdin.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "wsv", "output format: wsv, csv, tsv, json or ndjson")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitError
+	}
+	outFormat, err := convert.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv query: %v\n", err)
+		return exitError
+	}
+	q, err := query.Parse(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv query: %v\n", err)
+		return exitError
+	}
+	name, in, err := openInput(q.From, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv query: %v\n", err)
+		return exitError
+	}
+	defer in.Close()
+	doc, err := q.Run(reader.NewReader(in))
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv query: %s: %v\n", name, err)
+		return exitError
+	}
+	if err := convert.Encode(stdout, doc, outFormat, convert.Options{}); err != nil {
+		fmt.Fprintf(stderr, "wsv query: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
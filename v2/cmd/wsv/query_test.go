@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "countries.wsv")
+	data := "Country Capital Population\nJapan Tokyo 125700000\nIceland Reykjavik 372000\nIndia \"New Delhi\" 1428600000\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	q := `SELECT Country, Capital FROM "` + file + `" WHERE Population > 1000000 ORDER BY Country DESC LIMIT 10`
+	code := run([]string{"query", q}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code 0 but got %d: %s", code, stderr.String())
+	}
+	if exp := "Country  Capital\nJapan    Tokyo\nIndia    \"New Delhi\"\n"; stdout.String() != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"query", "--format", "csv", "SELECT Country FROM - WHERE Capital LIKE 'R%'"}, strings.NewReader(data), &stdout, &stderr)
+	if exp := "Country\nIceland\n"; code != exitOK || stdout.String() != exp {
+		t.Errorf("expected %q but got %d %q", exp, code, stdout.String())
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"query", "SELECT FROM -"}, strings.NewReader(""), &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "syntax error") {
+		t.Errorf("expected a syntax error but got %d %q", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"query", "SELECT Area FROM -"}, strings.NewReader("Country\nJapan\n"), &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), `"Area"`) {
+		t.Errorf("expected an unknown column but got %d %q", code, stderr.String())
+	}
+	if code := run([]string{"query"}, nil, &stdout, &stderr); code != exitError {
+		t.Errorf("expected a missing query to fail but got %d", code)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"unicode/utf16"
+
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/schema"
+)
+
+// the error kinds reported, in the order they are matched with errors.Is
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{reader.ErrBareQuote, "ErrBareQuote"},
+	{reader.ErrInvalidNull, "ErrInvalidNull"},
+	{reader.ErrCommentPlacement, "ErrCommentPlacement"},
+	{reader.ErrLineFeedTerm, "ErrLineFeedTerm"},
+	{reader.ErrFieldCount, "ErrFieldCount"},
+	{schema.ErrInvalidDeclaration, "ErrInvalidDeclaration"},
+	{schema.ErrMissingColumn, "ErrMissingColumn"},
+	{schema.ErrNotNullable, "ErrNotNullable"},
+	{schema.ErrDuplicateValue, "ErrDuplicateValue"},
+	{schema.ErrInvalidValue, "ErrInvalidValue"},
+	{schema.ErrNotInEnum, "ErrNotInEnum"},
+	{schema.ErrPatternMismatch, "ErrPatternMismatch"},
+}
+
+// the kind of errors that match none of errorKinds
+const unknownKind = "Error"
+
+// A diagnostic is a problem found in a file, lines and columns are 1-indexed and 0 when unknown
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// the bytes around the column
+	Context string `json:"context,omitempty"`
+	// the column counted in UTF-16 code units as SARIF does
+	utf16Column int
+}
+
+func runValidate(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: wsv validate [flags] [files]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Reports every parse error, field count mismatch and schema violation of WSV files and exits with 1 if there are any,")
+		fmt.Fprintln(stderr, "reads stdin when no file or - is given.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "text", "output format: text, json or sarif")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(stderr, "wsv validate: unknown format %q\n", *format)
+		return exitError
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	code := exitOK
+	diags := make([]diagnostic, 0)
+	for _, file := range files {
+		name, data, err := readInput(file, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "wsv validate: %v\n", err)
+			code = exitError
+			continue
+		}
+		found, err := validate(name, data)
+		if err != nil {
+			fmt.Fprintf(stderr, "wsv validate: %s: %v\n", name, err)
+			code = exitError
+			continue
+		}
+		diags = append(diags, found...)
+	}
+
+	var err error
+	switch *format {
+	case "json":
+		err = writeJSON(stdout, diags)
+	case "sarif":
+		err = writeSARIF(stdout, diags)
+	default:
+		for _, d := range diags {
+			fmt.Fprintln(stdout, d)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "wsv validate: %v\n", err)
+		return exitError
+	}
+	if len(diags) > 0 {
+		code = max(code, exitFailure)
+	}
+	return code
+}
+
+// validate reads the whole file in lenient mode and returns a diagnostic for every error recorded
+// and every line with fewer fields than the header
+func validate(name string, data []byte) ([]diagnostic, error) {
+	r := reader.NewReader(bytes.NewReader(data))
+	r.Lenient = true
+	r.NullTrailingColumns = false
+	diags := make([]diagnostic, 0)
+	recorded := 0
+	for line, err := range r.All() {
+		if err != nil {
+			return nil, err
+		}
+		// a line with errors is only partially recovered, its field count is not checked
+		if r.ErrorCount() > recorded {
+			for _, err := range r.Errors()[recorded:] {
+				d := newDiagnostic(name, err)
+				d.utf16Column = utf16Column(data, d.Line, d.Column)
+				diags = append(diags, d)
+			}
+			recorded = r.ErrorCount()
+			continue
+		}
+		if line.IsHeaderLine() || line.FieldCount() == 0 || line.FieldCount() >= len(r.Headers()) {
+			continue
+		}
+		diags = append(diags, diagnostic{
+			File:    name,
+			Line:    line.LineNumber(),
+			Kind:    "ErrFieldCount",
+			Message: fmt.Sprintf("%v, %d of %d fields", reader.ErrFieldCount, line.FieldCount(), len(r.Headers())),
+		})
+	}
+	return diags, nil
+}
+
+func newDiagnostic(file string, err error) diagnostic {
+	d := diagnostic{File: file, Kind: unknownKind, Message: err.Error()}
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			d.Kind = k.kind
+			break
+		}
+	}
+	var parseErr *reader.ParseError
+	var violation schema.Violation
+	switch {
+	case errors.As(err, &parseErr):
+		d.Line = parseErr.Line
+		d.Message = parseErr.Err.Error()
+		d.Context = string(parseErr.NeighborBytes)
+		// the column of field count and comment placement errors is not known
+		if !errors.Is(err, reader.ErrFieldCount) && !errors.Is(err, reader.ErrCommentPlacement) {
+			d.Column = parseErr.Column + 1
+		}
+	case errors.As(err, &violation):
+		d.Line = violation.Line
+		d.Field = violation.Column
+		d.Message = violation.Err.Error()
+	}
+	return d
+}
+
+// converts the 1-indexed byte column on a line of the data to UTF-16 code units, 0 stays unknown
+func utf16Column(data []byte, line int, column int) int {
+	lines := bytes.Split(data, []byte("\n"))
+	if column <= 0 || line <= 0 || line > len(lines) {
+		return column
+	}
+	text := lines[line-1]
+	n := 1
+	for _, r := range string(text[:min(column-1, len(text))]) {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+func (d diagnostic) String() string {
+	pos := fmt.Sprintf("%s:%d", d.File, d.Line)
+	if d.Column > 0 {
+		pos += fmt.Sprintf(":%d", d.Column)
+	}
+	s := fmt.Sprintf("%s: %s: %s", pos, d.Kind, d.Message)
+	if d.Field != "" {
+		s += fmt.Sprintf(" (column %q)", d.Field)
+	}
+	if d.Context != "" {
+		s += fmt.Sprintf(" near %q", d.Context)
+	}
+	return s
+}
+
+func writeJSON(w io.Writer, diags []diagnostic) error {
+	b, err := json.MarshalIndent(diags, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// SARIF 2.1.0 log, limited to the properties written by writeSARIF
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	Results    []sarifResult `json:"results"`
+	ColumnKind string        `json:"columnKind"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func writeSARIF(w io.Writer, diags []diagnostic) error {
+	rules := make([]sarifRule, 0, len(errorKinds))
+	for _, k := range errorKinds {
+		rules = append(rules, sarifRule{ID: k.kind, ShortDescription: sarifMessage{Text: k.err.Error()}})
+	}
+	rules = append(rules, sarifRule{ID: unknownKind, ShortDescription: sarifMessage{Text: "the file could not be read"}})
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		msg := d.Message
+		if d.Field != "" {
+			msg += fmt.Sprintf(" (column %q)", d.Field)
+		}
+		res := sarifResult{RuleID: d.Kind, Level: "error", Message: sarifMessage{Text: msg}}
+		// stdin has no URI, its results have no location
+		if d.File != stdinName {
+			res.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: fileURI(d.File)},
+				Region:           sarifRegion{StartLine: max(d.Line, 1), StartColumn: d.utf16Column},
+			}}}
+		}
+		results = append(results, res)
+	}
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "wsv",
+				InformationURI: "https://github.com/internetcalifornia/wsv",
+				Rules:          rules,
+			}},
+			Results:    results,
+			ColumnKind: "utf16CodeUnits",
+		}},
+	}
+	b, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// the URI of a file path, relative paths stay relative references
+func fileURI(file string) string {
+	u := url.URL{Path: filepath.ToSlash(file)}
+	if filepath.IsAbs(file) {
+		u.Scheme = "file"
+		if filepath.VolumeName(file) != "" {
+			// a Windows drive letter, file:///C:/...
+			u.Path = "/" + u.Path
+		}
+	}
+	return u.String()
+}
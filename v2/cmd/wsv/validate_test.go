@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const invalid = `#@schema Name:string Age:int
+Name Age Color
+Scott "33 red
+Jane -x blue
+Zak 1 2 3
+Bob 4
+Al old green
+`
+
+func TestValidateText(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"validate"}, strings.NewReader(invalid), &stdout, &stderr)
+	if code != exitFailure {
+		t.Errorf("expected exit code 1 but got %d: %s", code, stderr.String())
+	}
+	exp := []string{
+		"<stdin>:3:7: ErrBareQuote:",
+		"<stdin>:4:6: ErrInvalidNull:",
+		"<stdin>:5: ErrFieldCount:",
+		"<stdin>:6: ErrFieldCount: wrong number of fields, 2 of 3 fields",
+		`<stdin>:7: ErrInvalidValue: value does not match the column type int (column "Age")`,
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(exp) {
+		t.Errorf("expected %d diagnostics but got\n%s", len(exp), stdout.String())
+		return
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, exp[i]) {
+			t.Errorf("expected diagnostic %d to start with %q but got %q", i, exp[i], line)
+		}
+	}
+
+	stdout.Reset()
+	code = run([]string{"validate"}, strings.NewReader("Name Age\nScott 33\n"), &stdout, &stderr)
+	if code != exitOK || stdout.Len() != 0 {
+		t.Errorf("expected a valid file to pass but got %d %q", code, stdout.String())
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "people.wsv")
+	if err := os.WriteFile(file, []byte(invalid), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	run([]string{"validate", "--format", "json", file}, nil, &stdout, &stderr)
+	var diags []diagnostic
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(diags) != 5 {
+		t.Errorf("expected 5 diagnostics but got %d", len(diags))
+		return
+	}
+	d := diags[0]
+	if d.File != file || d.Line != 3 || d.Column != 7 || d.Kind != "ErrBareQuote" || d.Context == "" {
+		t.Errorf("expected a bare quote on line 3 but got %+v", d)
+	}
+	if d := diags[4]; d.Field != "Age" || d.Kind != "ErrInvalidValue" {
+		t.Errorf("expected a schema violation for Age but got %+v", d)
+	}
+}
+
+func TestValidateSARIF(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "people list.wsv")
+	// ä is two bytes but one UTF-16 code unit, the invalid null is on byte 7 and column 6
+	data := strings.Replace(invalid, "Jane", "Jäne", 1)
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	run([]string{"validate", "--format", "sarif", file}, nil, &stdout, &stderr)
+	var log sarifLog
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Error(err)
+		return
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 5 {
+		t.Errorf("expected a run with 5 results but got %+v", log)
+		return
+	}
+	if log.Runs[0].ColumnKind != "utf16CodeUnits" {
+		t.Errorf("expected columns in UTF-16 code units but got %q", log.Runs[0].ColumnKind)
+	}
+	res := log.Runs[0].Results[1]
+	loc := res.Locations[0].PhysicalLocation
+	if res.RuleID != "ErrInvalidNull" || loc.Region.StartLine != 4 || loc.Region.StartColumn != 6 {
+		t.Errorf("expected an invalid null on line 4 column 6 but got %+v", res)
+	}
+	if exp := "file://" + filepath.ToSlash(dir) + "/people%20list.wsv"; loc.ArtifactLocation.URI != exp {
+		t.Errorf("expected the URI %q but got %q", exp, loc.ArtifactLocation.URI)
+	}
+	rules := make(map[string]bool)
+	for _, rule := range log.Runs[0].Tool.Driver.Rules {
+		rules[rule.ID] = true
+	}
+	for _, kind := range []string{"ErrInvalidNull", "ErrFieldCount", unknownKind} {
+		if !rules[kind] {
+			t.Errorf("expected the rule %s to be declared", kind)
+		}
+	}
+
+	stdout.Reset()
+	run([]string{"validate", "--format", "sarif"}, strings.NewReader(invalid), &stdout, &stderr)
+	log = sarifLog{}
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Error(err)
+		return
+	}
+	if res := log.Runs[0].Results[0]; len(res.Locations) != 0 {
+		t.Errorf("expected results of stdin to have no location but got %+v", res.Locations)
+	}
+
+	if code := run([]string{"validate", "--format", "xml"}, strings.NewReader(invalid), &stdout, &stderr); code != exitError {
+		t.Errorf("expected an unknown format to fail but got %d", code)
+	}
+}
@@ -0,0 +1,393 @@
+// Convert WSV documents to and from CSV, TSV, JSON and NDJSON.
+//
+// JSON is an array of objects keyed by the header, NDJSON is one object per line. WSV nulls are written as JSON null
+// and as the NullSentinel in CSV and TSV cells. Comments and empty lines only exist in WSV and are dropped.
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrNoHeaders     = errors.New("JSON objects need a header line to key the values by")
+	ErrInvalidJSON   = errors.New("JSON must be objects with values that are strings, numbers, booleans or null")
+)
+
+type Format int
+
+const (
+	WSV Format = iota
+	CSV
+	TSV
+	JSON
+	NDJSON
+)
+
+var formatNames = []string{"wsv", "csv", "tsv", "json", "ndjson"}
+
+func (f Format) String() string {
+	if int(f) < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// Returns the format with the name, as returned by Format.String
+func ParseFormat(name string) (Format, error) {
+	i := slices.Index(formatNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("%w %q", ErrUnknownFormat, name)
+	}
+	return Format(i), nil
+}
+
+// Returns the format of a file from its extension, such as ".csv"
+func FormatOf(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "jsonl" {
+		return NDJSON, nil
+	}
+	return ParseFormat(ext)
+}
+
+type Options struct {
+	// Value of a null in CSV and TSV, cells with the value are read as null. Empty by default,
+	// which makes empty cells null
+	NullSentinel string
+}
+
+// a line to convert, header is set for the header line
+type row struct {
+	fields []record.RecordField
+	header bool
+}
+
+// Encode writes the document in the format
+func Encode(w io.Writer, doc *document.Document, f Format, opts Options) error {
+	if f == WSV {
+		b, err := doc.WriteAll()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	rows := func(yield func(row, error) bool) {
+		for _, line := range doc.Lines() {
+			if !yield(row{fields: line.Fields(), header: line.IsHeader()}, nil) {
+				return
+			}
+		}
+	}
+	return encodeRows(w, rows, f, opts)
+}
+
+// EncodeReader writes the remaining lines of the reader in the format, one line at a time
+func EncodeReader(w io.Writer, r *reader.Reader, f Format, opts Options) error {
+	if f == WSV {
+		doc, err := r.ToDocument()
+		if err != nil {
+			return err
+		}
+		return Encode(w, doc, f, opts)
+	}
+	rows := func(yield func(row, error) bool) {
+		for line, err := range r.All() {
+			if err != nil {
+				yield(row{}, err)
+				return
+			}
+			fields := make([]record.RecordField, 0, line.FieldCount())
+			for _, f := range line.Fields() {
+				fields = append(fields, *f)
+			}
+			if !yield(row{fields: fields, header: line.IsHeaderLine()}, nil) {
+				return
+			}
+		}
+	}
+	return encodeRows(w, rows, f, opts)
+}
+
+func encodeRows(w io.Writer, rows iter.Seq2[row, error], f Format, opts Options) error {
+	switch f {
+	case CSV, TSV:
+		cw := csv.NewWriter(w)
+		if f == TSV {
+			cw.Comma = '\t'
+		}
+		for row, err := range rows {
+			if err != nil {
+				return err
+			}
+			if len(row.fields) == 0 {
+				continue
+			}
+			rec := make([]string, len(row.fields))
+			for i, field := range row.fields {
+				rec[i] = field.Value
+				if field.IsNull {
+					rec[i] = opts.NullSentinel
+				}
+			}
+			if err := cw.Write(rec); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case JSON, NDJSON:
+		return encodeJSON(w, rows, f == NDJSON)
+	}
+	return fmt.Errorf("%w %s", ErrUnknownFormat, f)
+}
+
+func encodeJSON(w io.Writer, rows iter.Seq2[row, error], ndjson bool) error {
+	var headers []string
+	first := true
+	if !ndjson {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+	}
+	for row, err := range rows {
+		if err != nil {
+			return err
+		}
+		if row.header {
+			headers = make([]string, len(row.fields))
+			for i, field := range row.fields {
+				headers[i] = field.Value
+			}
+			continue
+		}
+		if len(row.fields) == 0 {
+			continue
+		}
+		if headers == nil {
+			return ErrNoHeaders
+		}
+		buf := make([]byte, 0, 64)
+		if !ndjson {
+			if !first {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, "\n  "...)
+		}
+		buf = appendObject(buf, headers, row.fields)
+		if ndjson {
+			buf = append(buf, '\n')
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		first = false
+	}
+	if ndjson {
+		return nil
+	}
+	end := "\n]\n"
+	if first {
+		end = "]\n"
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
+
+// appends the fields as a JSON object with the keys in the order of the headers, fields without a header are left out
+func appendObject(buf []byte, headers []string, fields []record.RecordField) []byte {
+	buf = append(buf, '{')
+	for i, field := range fields {
+		if i >= len(headers) {
+			break
+		}
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, _ := json.Marshal(headers[i])
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		if field.IsNull {
+			buf = append(buf, "null"...)
+			continue
+		}
+		val, _ := json.Marshal(field.Value)
+		buf = append(buf, val...)
+	}
+	return append(buf, '}')
+}
+
+// Decode reads a document in the format, the first CSV or TSV record is the header line.
+// The header line of JSON is made of the keys of the objects in the order they are first seen,
+// numbers and booleans keep their JSON text.
+func Decode(r io.Reader, f Format, opts Options) (*document.Document, error) {
+	switch f {
+	case WSV:
+		return reader.NewReader(r).ToDocument()
+	case CSV, TSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		if f == TSV {
+			cr.Comma = '\t'
+			cr.LazyQuotes = true
+		}
+		doc := document.NewDocument()
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				return doc, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			line, err := doc.AddLine()
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range rec {
+				if v == opts.NullSentinel && line.LineNumber() != 1 {
+					err = line.AppendNull()
+				} else {
+					err = line.Append(v)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+			// short records are padded with nulls like the trailing columns of a WSV line
+			for line.LineNumber() != 1 && line.FieldCount() < len(doc.Headers()) {
+				if err := line.AppendNull(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case JSON, NDJSON:
+		return decodeJSON(r, f == NDJSON)
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnknownFormat, f)
+}
+
+// the keys and values of a decoded JSON object in order, the value of a null is nil
+type object struct {
+	keys   []string
+	values []*string
+}
+
+func decodeJSON(r io.Reader, ndjson bool) (*document.Document, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if !ndjson {
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, err
+		}
+	}
+	headers := make([]string, 0)
+	objects := make([]object, 0)
+	for dec.More() {
+		obj, err := decodeObject(dec)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range obj.keys {
+			if !slices.Contains(headers, k) {
+				headers = append(headers, k)
+			}
+		}
+		objects = append(objects, obj)
+	}
+	if !ndjson {
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+	}
+
+	doc := document.NewDocument()
+	if len(objects) == 0 {
+		return doc, nil
+	}
+	if _, err := doc.AppendLine(document.Fields(headers...)...); err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		line, err := doc.AddLine()
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range headers {
+			i := slices.Index(obj.keys, h)
+			if i < 0 || obj.values[i] == nil {
+				err = line.AppendNull()
+			} else {
+				err = line.Append(*obj.values[i])
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+func decodeObject(dec *json.Decoder) (object, error) {
+	var obj object
+	if err := expectDelim(dec, '{'); err != nil {
+		return obj, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return obj, err
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return obj, err
+		}
+		var val *string
+		switch {
+		case bytes.Equal(raw, []byte("null")):
+		case raw[0] == '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return obj, err
+			}
+			val = &s
+		case raw[0] == '{' || raw[0] == '[':
+			return obj, fmt.Errorf("%w, %q has a nested value", ErrInvalidJSON, key)
+		default:
+			s := string(raw)
+			val = &s
+		}
+		obj.keys = append(obj.keys, key)
+		obj.values = append(obj.values, val)
+	}
+	return obj, expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("%w, expected %s but got %v", ErrInvalidJSON, delim, tok)
+	}
+	return nil
+}
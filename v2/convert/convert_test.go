@@ -0,0 +1,143 @@
+package convert_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/convert"
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+func people() *document.Document {
+	doc := document.NewDocument()
+	doc.AppendLine(document.Fields("Name", "Age", "City")...)
+	doc.AppendLine(document.Fields("Scott", "33", "New York, NY")...)
+	doc.AppendLine(document.Field("Jane"), document.Null(), document.Field(`say "hi"`))
+	return doc
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		format convert.Format
+		opts   convert.Options
+		exp    string
+	}{
+		{convert.CSV, convert.Options{}, "Name,Age,City\nScott,33,\"New York, NY\"\nJane,,\"say \"\"hi\"\"\"\n"},
+		{convert.CSV, convert.Options{NullSentinel: "NULL"}, "Name,Age,City\nScott,33,\"New York, NY\"\nJane,NULL,\"say \"\"hi\"\"\"\n"},
+		{convert.TSV, convert.Options{}, "Name\tAge\tCity\nScott\t33\tNew York, NY\nJane\t\t\"say \"\"hi\"\"\"\n"},
+		{convert.JSON, convert.Options{}, "[\n  {\"Name\":\"Scott\",\"Age\":\"33\",\"City\":\"New York, NY\"},\n  {\"Name\":\"Jane\",\"Age\":null,\"City\":\"say \\\"hi\\\"\"}\n]\n"},
+		{convert.NDJSON, convert.Options{}, "{\"Name\":\"Scott\",\"Age\":\"33\",\"City\":\"New York, NY\"}\n{\"Name\":\"Jane\",\"Age\":null,\"City\":\"say \\\"hi\\\"\"}\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := convert.Encode(&buf, people(), tt.format, tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if buf.String() != tt.exp {
+			t.Errorf("%s: expected\n%s\nbut got\n%s", tt.format, tt.exp, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	convert.Encode(&buf, document.NewDocument(), convert.JSON, convert.Options{})
+	if buf.String() != "[]\n" {
+		t.Errorf("expected an empty array but got %q", buf.String())
+	}
+}
+
+func TestEncodeReader(t *testing.T) {
+	r := reader.NewReader(strings.NewReader("Name Age\n# comment\nScott 33\nJane -\n"))
+	var buf bytes.Buffer
+	err := convert.EncodeReader(&buf, r, convert.NDJSON, convert.Options{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if exp := "{\"Name\":\"Scott\",\"Age\":\"33\"}\n{\"Name\":\"Jane\",\"Age\":null}\n"; buf.String() != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, buf.String())
+	}
+
+	r = reader.NewReader(strings.NewReader("Name Age\nScott 33\n"))
+	r.IncludesHeader = false
+	err = convert.EncodeReader(&buf, r, convert.JSON, convert.Options{})
+	if !errors.Is(err, convert.ErrNoHeaders) {
+		t.Errorf("expected the missing headers to be an error but got %v", err)
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	exp, err := people().WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, f := range []convert.Format{convert.WSV, convert.CSV, convert.TSV, convert.JSON, convert.NDJSON} {
+		var buf bytes.Buffer
+		if err := convert.Encode(&buf, people(), f, convert.Options{NullSentinel: "NULL"}); err != nil {
+			t.Errorf("%s: %v", f, err)
+			continue
+		}
+		doc, err := convert.Decode(&buf, f, convert.Options{NullSentinel: "NULL"})
+		if err != nil {
+			t.Errorf("%s: %v", f, err)
+			continue
+		}
+		out, err := doc.WriteAll()
+		if err != nil {
+			t.Errorf("%s: %v", f, err)
+			continue
+		}
+		if string(out) != string(exp) {
+			t.Errorf("%s: expected\n%s\nbut got\n%s", f, exp, out)
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	data := `[{"Name": "Scott", "Age": 33, "Admin": true}, {"Name": "Jane", "City": "Boston", "Age": null}]`
+	doc, err := convert.Decode(strings.NewReader(data), convert.JSON, convert.Options{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, _ := doc.WriteAll()
+	if exp := "Name   Age  Admin  City\nScott  33   true   -\nJane   -    -      Boston\n"; string(out) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, out)
+	}
+
+	for _, data := range []string{`{"Name": "Scott"}`, `[{"Name": {"Given": "Scott"}}]`, `[["Scott"]]`} {
+		_, err = convert.Decode(strings.NewReader(data), convert.JSON, convert.Options{})
+		if !errors.Is(err, convert.ErrInvalidJSON) {
+			t.Errorf("expected %s to be invalid but got %v", data, err)
+		}
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	doc, err := convert.Decode(strings.NewReader("Name,Age,City\nScott,,Boston\nJane,21\n"), convert.CSV, convert.Options{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, _ := doc.WriteAll()
+	if exp := "Name   Age  City\nScott  -    Boston\nJane   21   -\n"; string(out) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, out)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if f, err := convert.FormatOf("data/partners.CSV"); err != nil || f != convert.CSV {
+		t.Errorf("expected csv but got %s %v", f, err)
+	}
+	if f, err := convert.FormatOf("events.jsonl"); err != nil || f != convert.NDJSON {
+		t.Errorf("expected ndjson but got %s %v", f, err)
+	}
+	if _, err := convert.ParseFormat("xml"); !errors.Is(err, convert.ErrUnknownFormat) {
+		t.Errorf("expected an unknown format but got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+var (
+	ErrNoHeaders    = errors.New("document does not have a header line")
+	ErrColumnExists = errors.New("column already exists")
+)
+
+// Inserts a column named name at the index at, 0 inserts it before the first column and len(doc.Headers()) after the last one.
+//
+// fill is called with every data line and returns the value of the new field, or false for a null. With a nil fill every
+// value is null. Lines without fields, like comments and empty lines, are left as they are.
+func (doc *Document) InsertColumn(at int, name string, fill func(DocumentLine) (string, bool)) error {
+	if err := doc.checkColumnEdit(); err != nil {
+		return err
+	}
+	if at < 0 || at > len(doc.headers) {
+		return fmt.Errorf("%w: column index %d of %d columns", ErrFieldIndexedNotFound, at, len(doc.headers))
+	}
+	if slices.Contains(doc.headers, name) {
+		return fmt.Errorf("%w: %q", ErrColumnExists, name)
+	}
+	doc.fillShortLines()
+	doc.headers = slices.Insert(doc.headers, at, name)
+	doc.editColumns(func(line *documentLine) {
+		field := record.RecordField{IsNull: true}
+		if line.IsHeader() {
+			field = record.RecordField{Value: name, IsHeader: true}
+		} else if fill != nil {
+			if val, ok := fill(line); ok {
+				field = record.RecordField{Value: val}
+			}
+		}
+		line.fields = slices.Insert(line.fields, at, field)
+		line.SetFormat(nil)
+	})
+	return nil
+}
+
+// Deletes the column with the header name from every line
+func (doc *Document) DeleteColumn(name string) error {
+	if err := doc.checkColumnEdit(); err != nil {
+		return err
+	}
+	i, err := doc.columnIndex(name)
+	if err != nil {
+		return err
+	}
+	doc.fillShortLines()
+	doc.headers = slices.Delete(doc.headers, i, i+1)
+	doc.editColumns(func(line *documentLine) {
+		line.fields = slices.Delete(line.fields, i, i+1)
+		line.SetFormat(nil)
+	})
+	return nil
+}
+
+// Renames the column with the header old to new, the fields of every line are renamed as well
+func (doc *Document) RenameColumn(old string, new string) error {
+	if err := doc.checkColumnEdit(); err != nil {
+		return err
+	}
+	i, err := doc.columnIndex(old)
+	if err != nil {
+		return err
+	}
+	if old != new && slices.Contains(doc.headers, new) {
+		return fmt.Errorf("%w: %q", ErrColumnExists, new)
+	}
+	doc.renameColumn(i, new)
+	return nil
+}
+
+// Moves the column with the header name to the index to, the other columns keep their order
+func (doc *Document) MoveColumn(name string, to int) error {
+	if err := doc.checkColumnEdit(); err != nil {
+		return err
+	}
+	i, err := doc.columnIndex(name)
+	if err != nil {
+		return err
+	}
+	if to < 0 || to >= len(doc.headers) {
+		return fmt.Errorf("%w: column index %d of %d columns", ErrFieldIndexedNotFound, to, len(doc.headers))
+	}
+	order := make([]int, 0, len(doc.headers))
+	for c := range doc.headers {
+		if c != i {
+			order = append(order, c)
+		}
+	}
+	doc.permuteColumns(slices.Insert(order, to, i))
+	return nil
+}
+
+// Reorders the columns so the columns named come first in the order given, the columns not named follow in their
+// current order
+func (doc *Document) ReorderColumns(names ...string) error {
+	if err := doc.checkColumnEdit(); err != nil {
+		return err
+	}
+	order := make([]int, 0, len(doc.headers))
+	for _, name := range names {
+		i, err := doc.columnIndex(name)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(order, i) {
+			return fmt.Errorf("%w: %q is named more than once", ErrColumnExists, name)
+		}
+		order = append(order, i)
+	}
+	for c := range doc.headers {
+		if !slices.Contains(order, c) {
+			order = append(order, c)
+		}
+	}
+	doc.permuteColumns(order)
+	return nil
+}
+
+func (doc *Document) checkColumnEdit() error {
+	if doc.startedWriting {
+		return &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	if !doc.HasHeaders() || doc.headerLine == 0 {
+		return ErrNoHeaders
+	}
+	return nil
+}
+
+// returns the index of the first column with the header name
+func (doc *Document) columnIndex(name string) (int, error) {
+	i := slices.Index(doc.headers, name)
+	if i < 0 {
+		return 0, fmt.Errorf("%w: column %q", ErrFieldNameNotFound, name)
+	}
+	return i, nil
+}
+
+// renames the column at index i in the headers and in every line
+func (doc *Document) renameColumn(i int, name string) {
+	doc.headers[i] = name
+	doc.editColumns(func(line *documentLine) {
+		if line.IsHeader() && i < len(line.fields) {
+			line.fields[i].Value = name
+			line.fields[i].IsNull = false
+		}
+	})
+}
+
+// moves the fields of every line so the column at order[i] becomes column i, fields after the headers stay at the end
+func (doc *Document) permuteColumns(order []int) {
+	doc.fillShortLines()
+	headers := make([]string, len(order))
+	for i, c := range order {
+		headers[i] = doc.headers[c]
+	}
+	doc.headers = headers
+	doc.editColumns(func(line *documentLine) {
+		fields := make([]record.RecordField, 0, len(line.fields))
+		for _, c := range order {
+			fields = append(fields, line.fields[c])
+		}
+		if len(line.fields) > len(order) {
+			fields = append(fields, line.fields[len(order):]...)
+		}
+		line.fields = fields
+		line.SetFormat(nil)
+	})
+}
+
+// fills the data lines with fewer fields than there are headers with nulls, so every field stays in its column when the
+// columns are edited
+func (doc *Document) fillShortLines() {
+	doc.editColumns(func(line *documentLine) {
+		for len(line.fields) < len(doc.headers) {
+			line.fields = append(line.fields, record.RecordField{IsNull: true})
+			line.SetFormat(nil)
+		}
+	})
+}
+
+// calls edit with every line that has fields, then brings the field indexes, field names, field counts and column
+// widths in line with the headers
+func (doc *Document) editColumns(edit func(line *documentLine)) {
+	for _, l := range doc.lines {
+		line, ok := l.(*documentLine)
+		if !ok || len(line.fields) == 0 {
+			continue
+		}
+		edit(line)
+		for i := range line.fields {
+			field := &line.fields[i]
+			field.FieldIndex = i
+			field.FieldName = ""
+			if i < len(doc.headers) {
+				field.FieldName = doc.headers[i]
+			}
+			field.IsHeader = line.IsHeader()
+		}
+		line.fieldCount = len(line.fields)
+	}
+	doc.recalculateMaxFieldLengths()
+}
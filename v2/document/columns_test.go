@@ -0,0 +1,135 @@
+package document
+
+import (
+	"errors"
+	"testing"
+)
+
+func columnDocument() *Document {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age", "City")...)
+	doc.AppendLine(Field("Scott"), Field("33"), Field("Chicago"))
+	doc.AddLine()
+	line, _ := doc.Line(3)
+	line.UpdateComment("no one")
+	doc.AppendLine(Field("Jane"), Null(), Field("Los Angeles"))
+	return doc
+}
+
+func expectDocument(t *testing.T, name string, doc *Document, exp string) {
+	t.Helper()
+	data, err := doc.WriteAll()
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if string(data) != exp {
+		t.Errorf("%s: expected\n%s\nbut got\n%s", name, exp, data)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	doc := columnDocument()
+	err := doc.InsertColumn(1, "Initial", func(line DocumentLine) (string, bool) {
+		f, _ := line.FieldByName("Name")
+		return f.Value[:1], f.Value != "Jane"
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "insert", doc, "Name   Initial  Age  City\nScott  S        33   Chicago\n#no one\nJane   -        -    \"Los Angeles\"\n")
+
+	doc = columnDocument()
+	if err := doc.DeleteColumn("City"); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "delete", doc, "Name   Age\nScott  33\n#no one\nJane   -\n")
+
+	doc = columnDocument()
+	if err := doc.RenameColumn("City", "Home Town"); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "rename", doc, "Name   Age  \"Home Town\"\nScott  33   Chicago\n#no one\nJane   -    \"Los Angeles\"\n")
+	line, _ := doc.Line(4)
+	if f, err := line.FieldByName("Home Town"); err != nil || f.Value != "Los Angeles" {
+		t.Errorf("expected the field to be renamed but got %v %v", f, err)
+	}
+
+	doc = columnDocument()
+	if err := doc.MoveColumn("Name", 2); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "move", doc, "Age  City           Name\n33   Chicago        Scott\n#no one\n-    \"Los Angeles\"  Jane\n")
+	line, _ = doc.Line(2)
+	if f, _ := line.Field(2); f.FieldIndex != 2 || f.FieldName != "Name" || f.Value != "Scott" {
+		t.Errorf("expected the field Name at index 2 but got %+v", f)
+	}
+
+	doc = columnDocument()
+	if err := doc.ReorderColumns("City", "Name"); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "reorder", doc, "City           Name   Age\nChicago        Scott  33\n#no one\n\"Los Angeles\"  Jane   -\n")
+
+	doc = columnDocument()
+	if err := doc.UpdateHeader(0, "First Name"); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "update header", doc, "\"First Name\"  Age  City\nScott         33   Chicago\n#no one\nJane          -    \"Los Angeles\"\n")
+	if headers := doc.Headers(); headers[0] != "First Name" || headers[1] != "Age" {
+		t.Errorf("expected the first header to be renamed but got %q", headers)
+	}
+}
+
+func TestColumnErrors(t *testing.T) {
+	doc := columnDocument()
+	if err := doc.InsertColumn(4, "Zip", nil); !errors.Is(err, ErrFieldIndexedNotFound) {
+		t.Errorf("expected ErrFieldIndexedNotFound but got %v", err)
+	}
+	if err := doc.InsertColumn(0, "Age", nil); !errors.Is(err, ErrColumnExists) {
+		t.Errorf("expected ErrColumnExists but got %v", err)
+	}
+	if err := doc.RenameColumn("Name", "City"); !errors.Is(err, ErrColumnExists) {
+		t.Errorf("expected ErrColumnExists but got %v", err)
+	}
+	if err := doc.DeleteColumn("Zip"); !errors.Is(err, ErrFieldNameNotFound) {
+		t.Errorf("expected ErrFieldNameNotFound but got %v", err)
+	}
+	if err := doc.MoveColumn("Name", 3); !errors.Is(err, ErrFieldIndexedNotFound) {
+		t.Errorf("expected ErrFieldIndexedNotFound but got %v", err)
+	}
+	if err := doc.ReorderColumns("Age", "Age"); !errors.Is(err, ErrColumnExists) {
+		t.Errorf("expected ErrColumnExists but got %v", err)
+	}
+	if err := doc.UpdateHeader(3, "Zip"); !errors.Is(err, ErrFieldIndexedNotFound) {
+		t.Errorf("expected ErrFieldIndexedNotFound but got %v", err)
+	}
+	if err := NewDocument().DeleteColumn("Name"); !errors.Is(err, ErrNoHeaders) {
+		t.Errorf("expected ErrNoHeaders but got %v", err)
+	}
+	doc.Write()
+	var werr *WriteError
+	if err := doc.DeleteColumn("Name"); !errors.As(err, &werr) {
+		t.Errorf("expected a WriteError after writing started but got %v", err)
+	}
+	if len(doc.Headers()) != 3 {
+		t.Errorf("expected the failed edits to leave the headers unchanged but got %q", doc.Headers())
+	}
+}
+
+func TestColumnsFillShortLines(t *testing.T) {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age", "City")...)
+	doc.AppendLine(Field("Scott"))
+	doc.AppendLine(Field("Jane"), Field("21"))
+	if err := doc.InsertColumn(2, "Country", nil); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "insert", doc, "Name   Age  Country  City\nScott  -    -        -\nJane   21   -        -\n")
+
+	doc.ResetWrite()
+	if err := doc.MoveColumn("Name", 3); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "move", doc, "Age  Country  City  Name\n-    -        -     Scott\n21   -        -     Jane\n")
+}
@@ -0,0 +1,199 @@
+package document
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+// Compares two non-null values of a column, returns a negative number when a sorts before b, a positive number when a
+// sorts after b and 0 when they are equal
+type Comparator = func(a string, b string) int
+
+// Compares strings in the collation order of a language, golang.org/x/text/collate.Collator implements it
+type Collator interface {
+	CompareString(a string, b string) int
+}
+
+// Where null values and missing fields are sorted
+type NullOrder int
+
+const (
+	// nulls are the smallest value, first when sorting ascending and last when sorting descending
+	NullsSmallest NullOrder = iota
+	// nulls are first in either direction
+	NullsFirst
+	// nulls are last in either direction
+	NullsLast
+)
+
+// The default comparator, integers and then floats are compared by value when both values are numbers, a number sorts
+// before text and text is compared byte wise
+func Auto(a string, b string) int {
+	if c, ok := compareParsed(a, b, parseInt, cmp.Compare[int64]); ok {
+		return c
+	}
+	c, _ := compareParsed(a, b, parseFloat, cmp.Compare[float64])
+	return c
+}
+
+// Compares the values as 64-bit integers, values that are not integers sort after integers and are compared byte wise
+func Numeric(a string, b string) int {
+	c, _ := compareParsed(a, b, parseInt, cmp.Compare[int64])
+	return c
+}
+
+// Compares the values as 64-bit floats, values that are not numbers sort after numbers and are compared byte wise
+func Float(a string, b string) int {
+	c, _ := compareParsed(a, b, parseFloat, cmp.Compare[float64])
+	return c
+}
+
+// Compares text with the runs of digits compared by value, so "file2" sorts before "file10"
+func Natural(a string, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			ei, ej := digitsEnd(a, i), digitsEnd(b, j)
+			na := strings.TrimLeft(a[i:ei], "0")
+			nb := strings.TrimLeft(b[j:ej], "0")
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			i, j = ei, ej
+			continue
+		}
+		ra, sa := utf8.DecodeRuneInString(a[i:])
+		rb, sb := utf8.DecodeRuneInString(b[j:])
+		if c := cmp.Compare(ra, rb); c != 0 {
+			return c
+		}
+		i += sa
+		j += sb
+	}
+	if c := cmp.Compare(len(a)-i, len(b)-j); c != 0 {
+		return c
+	}
+	// equal apart from leading zeros
+	return strings.Compare(a, b)
+}
+
+// Compares the values rune by rune ignoring the case of letters
+func CaseInsensitive(a string, b string) int {
+	for a != "" && b != "" {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if c := cmp.Compare(unicode.ToLower(ra), unicode.ToLower(rb)); c != 0 {
+			return c
+		}
+		a, b = a[sa:], b[sb:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// Returns a comparator for dates in the layout of time.Parse, values that are not dates sort after dates and are
+// compared byte wise
+func Date(layout string) Comparator {
+	return func(a string, b string) int {
+		c, _ := compareParsed(a, b, func(s string) (time.Time, bool) {
+			t, err := time.Parse(layout, s)
+			return t, err == nil
+		}, time.Time.Compare)
+		return c
+	}
+}
+
+// Returns a comparator using the collation order of the collator
+func Collate(c Collator) Comparator {
+	return c.CompareString
+}
+
+// compares the parsed values, a value that parses sorts before one that does not and values that do not parse are
+// compared byte wise, ok is false when either value did not parse
+func compareParsed[T any](a string, b string, parse func(string) (T, bool), compare func(T, T) int) (c int, ok bool) {
+	va, okA := parse(a)
+	vb, okB := parse(b)
+	switch {
+	case okA && okB:
+		return compare(va, vb), true
+	case okA:
+		return -1, false
+	case okB:
+		return 1, false
+	}
+	return strings.Compare(a, b), false
+}
+
+func parseInt(s string) (int64, bool) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, err == nil
+}
+
+func parseFloat(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+func digitsEnd(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// compares the field of the option for two lines, a missing field is compared as a null
+func (o SortOption) compare(a DocumentLine, b DocumentLine) int {
+	fa := sortField(a, o.FieldName)
+	fb := sortField(b, o.FieldName)
+	if o.Func != nil {
+		c := 0
+		if o.Func(fa, fb) {
+			c = -1
+		} else if o.Func(fb, fa) {
+			c = 1
+		}
+		if o.Desc {
+			return -c
+		}
+		return c
+	}
+	if fa.IsNull || fb.IsNull {
+		if fa.IsNull == fb.IsNull {
+			return 0
+		}
+		first := o.Nulls == NullsFirst || (o.Nulls == NullsSmallest && !o.Desc)
+		if fa.IsNull == first {
+			return -1
+		}
+		return 1
+	}
+	compare := o.Compare
+	if compare == nil {
+		compare = Auto
+	}
+	c := compare(fa.Value, fb.Value)
+	if o.Desc {
+		return -c
+	}
+	return c
+}
+
+func sortField(line DocumentLine, name string) *record.RecordField {
+	f, err := line.FieldByName(name)
+	if err != nil {
+		return &record.RecordField{FieldName: name, IsNull: true}
+	}
+	return f
+}
@@ -0,0 +1,143 @@
+package document
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+func TestComparators(t *testing.T) {
+	tests := []struct {
+		name    string
+		compare Comparator
+		vals    []string
+		exp     []string
+	}{
+		{"Auto", Auto, []string{"b", "10", "2.5", "a", "-1"}, []string{"-1", "2.5", "10", "a", "b"}},
+		{"Numeric", Numeric, []string{"10", "x", "9", "-3"}, []string{"-3", "9", "10", "x"}},
+		{"Float", Float, []string{"1e3", "2.5", "x", "-0.5"}, []string{"-0.5", "2.5", "1e3", "x"}},
+		{"Natural", Natural, []string{"file10", "file2", "file02", "file1b", "file"}, []string{"file", "file1b", "file02", "file2", "file10"}},
+		{"CaseInsensitive", CaseInsensitive, []string{"banana", "Apple", "cherry", "apple"}, []string{"Apple", "apple", "banana", "cherry"}},
+		{"Date", Date("02.01.2006"), []string{"01.02.2024", "x", "31.12.2023"}, []string{"31.12.2023", "01.02.2024", "x"}},
+		{"Collate", Collate(reverseCollator{}), []string{"a", "c", "b"}, []string{"c", "b", "a"}},
+	}
+	for _, test := range tests {
+		vals := slices.Clone(test.vals)
+		slices.SortStableFunc(vals, test.compare)
+		if !slices.Equal(vals, test.exp) {
+			t.Errorf("%s: expected %q but got %q", test.name, test.exp, vals)
+		}
+	}
+}
+
+type reverseCollator struct{}
+
+func (reverseCollator) CompareString(a string, b string) int {
+	return strings.Compare(b, a)
+}
+
+func sortDocument() *Document {
+	doc := NewDocument()
+	doc.AddLine()
+	line, _ := doc.Line(1)
+	line.UpdateComment("countries")
+	doc.AppendLine(Fields("Country", "Capital", "Population")...)
+	doc.AppendLine(Field("Japan"), Field("Tokyo"), Field("125700000"))
+	doc.AppendLine(Field("Iceland"), Null(), Field("372000"))
+	doc.AppendLine(Field("India"), Field("New Delhi"), Field("1428600000"))
+	doc.AppendLine(Field("India"), Field("Mumbai"), Null())
+	doc.AppendLine(Field("Japan"), Field("Osaka"), Field("2750000"))
+	return doc
+}
+
+func capitals(doc *Document) []string {
+	vals := make([]string, 0)
+	for _, line := range doc.Lines()[2:] {
+		f, _ := line.FieldByName("Capital")
+		if f.IsNull {
+			vals = append(vals, "-")
+			continue
+		}
+		vals = append(vals, f.Value)
+	}
+	return vals
+}
+
+func TestSortByOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []SortOption
+		exp  []string
+	}{
+		{"stable", []SortOption{{FieldName: "Country"}}, []string{"-", "New Delhi", "Mumbai", "Tokyo", "Osaka"}},
+		{"multiple keys", []SortOption{{FieldName: "Capital"}, {FieldName: "Country", Desc: true}}, []string{"Osaka", "Tokyo", "Mumbai", "New Delhi", "-"}},
+		{"nulls smallest", []SortOption{{FieldName: "Population", Desc: true}}, []string{"New Delhi", "Tokyo", "Osaka", "-", "Mumbai"}},
+		{"nulls first", []SortOption{{FieldName: "Population", Desc: true, Nulls: NullsFirst}}, []string{"Mumbai", "New Delhi", "Tokyo", "Osaka", "-"}},
+		{"nulls last", []SortOption{{FieldName: "Capital", Nulls: NullsLast}}, []string{"Mumbai", "New Delhi", "Osaka", "Tokyo", "-"}},
+		{"comparator", []SortOption{{FieldName: "Capital", Compare: Collate(reverseCollator{})}}, []string{"-", "Tokyo", "Osaka", "New Delhi", "Mumbai"}},
+		{"func", []SortOption{{FieldName: "Capital", Func: func(prv *record.RecordField, curr *record.RecordField) bool {
+			if prv.IsNull || curr.IsNull {
+				return !prv.IsNull && curr.IsNull
+			}
+			return len(prv.Value) < len(curr.Value)
+		}}}, []string{"Tokyo", "Osaka", "Mumbai", "New Delhi", "-"}},
+	}
+	for _, test := range tests {
+		doc := sortDocument()
+		if err := doc.SortBy(test.opts...); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := capitals(doc); !slices.Equal(got, test.exp) {
+			t.Errorf("%s: expected %q but got %q", test.name, test.exp, got)
+		}
+		if line, _ := doc.Line(2); !line.IsHeader() || line.LineNumber() != 2 {
+			t.Errorf("%s: expected the header to stay on line 2", test.name)
+		}
+		if doc.Lines()[0].Comment() != "countries" {
+			t.Errorf("%s: expected the comment to stay on line 1", test.name)
+		}
+	}
+}
+
+func TestSortByUnknownField(t *testing.T) {
+	doc := sortDocument()
+	err := doc.SortBy(SortOption{FieldName: "Country"}, SortOption{FieldName: "Area"})
+	if !errors.Is(err, ErrFieldNotFoundForSortBy) {
+		t.Errorf("expected ErrFieldNotFoundForSortBy but got %v", err)
+	}
+	if got := capitals(doc); got[0] != "Tokyo" {
+		t.Errorf("expected the document to be unsorted but got %q", got)
+	}
+}
+
+func TestSortByKeepsLinesWithoutValues(t *testing.T) {
+	doc := sortDocument()
+	line, _ := doc.InsertLineAt(4)
+	line.UpdateComment("islands")
+	doc.AddLine()
+	if err := doc.SortBy(SortOption{FieldName: "Capital", Nulls: NullsLast}); err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := "#countries\n" +
+		"Country  Capital      Population\n" +
+		"India    Mumbai       -\n" +
+		"#islands\n" +
+		"India    \"New Delhi\"  1428600000\n" +
+		"Japan    Osaka        2750000\n" +
+		"Japan    Tokyo        125700000\n" +
+		"Iceland  -            372000\n" +
+		"\n"
+	if string(b) != exp {
+		t.Errorf("expected the comment and empty line to stay in place\n%s\nbut got\n%s", exp, b)
+	}
+}
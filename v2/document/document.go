@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"slices"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/internetcalifornia/wsv/v2/record"
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+	"github.com/internetcalifornia/wsv/v2/schema"
 	"github.com/internetcalifornia/wsv/v2/utils"
 )
 
@@ -30,6 +33,7 @@ var (
 	ErrFieldCount                   = errors.New("wrong number of fields")
 	ErrCannotSortNonTabularDocument = errors.New("the document is non-tabular and cannot be sorted")
 	ErrFieldNotFoundForSortBy       = errors.New("the field was not found")
+	ErrHeaderLineSet                = errors.New("values were already appended, the header line is set")
 )
 
 func (e *WriteError) Error() string {
@@ -57,6 +61,9 @@ type Document struct {
 	headers          []string
 	headerLine       int
 	hasHeaders       bool
+	encoding         reliabletxt.Encoding
+	writeBOM         bool
+	schema           *schema.Schema
 }
 
 func (doc *Document) SetPadding(rs []rune) error {
@@ -69,6 +76,50 @@ func (doc *Document) SetPadding(rs []rune) error {
 	return nil
 }
 
+// Sets the ReliableTXT encoding used by Write and WriteAll, the output starts with the byte order mark of the encoding.
+//
+// Without an encoding set the document is written as UTF-8 without a byte order mark.
+func (doc *Document) SetEncoding(enc reliabletxt.Encoding) error {
+	if enc.Preamble() == nil {
+		return reliabletxt.ErrInvalidEncoding
+	}
+	doc.encoding = enc
+	doc.writeBOM = true
+	return nil
+}
+
+// Returns the encoding used when writing the document
+func (doc *Document) Encoding() reliabletxt.Encoding {
+	return doc.encoding
+}
+
+// Sets the schema of the document, it is written as a `#@schema` comment before the first line unless a line before
+// the header that keeps its format already declares a schema. Set the schema to nil to omit the comment.
+func (doc *Document) SetSchema(s *schema.Schema) {
+	doc.schema = s
+}
+
+// whether a line before the header is written with its format and declares a schema, which is then not written again
+func (doc *Document) formattedSchemaLine() bool {
+	for _, line := range doc.lines {
+		if line.FieldCount() > 0 {
+			return false
+		}
+		if line.Format() == nil {
+			continue
+		}
+		if _, ok, _ := schema.ParseComment(line.Comment()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the schema of the document, or nil if none is set
+func (doc *Document) Schema() *schema.Schema {
+	return doc.schema
+}
+
 type appendLineField struct {
 	val    string
 	isNull bool
@@ -78,6 +129,17 @@ func (d *Document) Lines() []DocumentLine {
 	return d.lines
 }
 
+// All returns an iterator over the line number and line of every line in the document, lines are 1-indexed
+func (d *Document) All() iter.Seq2[int, DocumentLine] {
+	return func(yield func(int, DocumentLine) bool) {
+		for i, line := range d.lines {
+			if !yield(i+1, line) {
+				return
+			}
+		}
+	}
+}
+
 func Field(val string) appendLineField {
 	return appendLineField{val, false}
 }
@@ -152,24 +214,59 @@ func (doc *Document) AddLine() (DocumentLine, error) {
 type SortFunc = func(prv *record.RecordField, curr *record.RecordField) bool
 
 type SortOption struct {
-	// name of the field in the
+	// name of the field in the header
 	FieldName string
 	Desc      bool
+	// compares the values of the field, defaults to Auto
+	Compare Comparator
+	// where nulls and missing fields are sorted, defaults to NullsSmallest
+	Nulls NullOrder
+	// fully custom ordering used instead of Compare and Nulls, it is called with null fields as well
+	Func SortFunc
 }
 
-// Sorts the documents lines in place based on the sort options
+// Sorts the documents lines in place based on the sort options as if each option was applied in turn, the last option is
+// the primary key and each option before it breaks the ties of the ones after it. The sort is stable, lines that compare
+// equal keep their order.
 //
-// Will sort until finished or a field specified is not found, in which case a ErrFieldNotFoundForSortBy is returned
+// The header line, the lines before it and the lines without values, such as comments and empty lines, stay in place.
+// If a field specified is not a header the document is left unsorted and a ErrFieldNotFoundForSortBy is returned
 func (doc *Document) SortBy(sortOptions ...SortOption) error {
 	if !doc.Tabular {
 		return ErrCannotSortNonTabularDocument
 	}
+	if doc.HasHeaders() {
+		for _, sort := range sortOptions {
+			if !slices.Contains(doc.headers, sort.FieldName) {
+				return fmt.Errorf("%w: %q", ErrFieldNotFoundForSortBy, sort.FieldName)
+			}
+		}
+	}
 
-	for _, sort := range sortOptions {
-		slices.SortFunc(doc.lines, func(a DocumentLine, b DocumentLine) int {
-			return a.Compare(sort.FieldName, b, sort.Desc)
-		})
-
+	start := 0
+	if doc.HasHeaders() {
+		start = min(doc.headerLine, len(doc.lines))
+	}
+	// only the lines with values are sorted, into the positions they were in
+	slots := make([]int, 0, len(doc.lines)-start)
+	lines := make([]DocumentLine, 0, len(doc.lines)-start)
+	for i := start; i < len(doc.lines); i++ {
+		if doc.lines[i].FieldCount() == 0 {
+			continue
+		}
+		slots = append(slots, i)
+		lines = append(lines, doc.lines[i])
+	}
+	slices.SortStableFunc(lines, func(a DocumentLine, b DocumentLine) int {
+		for i := len(sortOptions) - 1; i >= 0; i-- {
+			if c := sortOptions[i].compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	for i, line := range lines {
+		doc.lines[slots[i]] = line
 	}
 	doc.ReIndexLineNumbers()
 	return nil
@@ -252,48 +349,65 @@ func (doc *Document) Write() ([]byte, error) {
 		return buf, ErrOmitHeaders
 	}
 	// if configured to be tabular, not an empty line, and has too little/many fields compared to headers return an error
-	if doc.Tabular && doc.currentWriteLine != 0 && line.FieldCount() != 0 && line.FieldCount() != len(doc.Headers()) {
-		return buf, &WriteError{line: line.LineNumber(), headerCount: len(doc.Headers()), fieldIndex: line.FieldCount(), err: ErrFieldCount}
+	if doc.Tabular && doc.currentWriteLine != 0 && line.FieldCount() != 0 && line.FieldCount() != doc.columnCount() {
+		return buf, &WriteError{line: line.LineNumber(), headerCount: doc.columnCount(), fieldIndex: line.FieldCount(), err: ErrFieldCount}
 	}
 
-	for i, field := range line.Fields() {
-		mw, err := doc.MaxColumnWidth(i)
+	if doc.currentWriteLine == 0 && doc.schema != nil && !doc.formattedSchemaLine() {
+		buf = append(buf, "#"+doc.schema.Comment()+"\n"...)
+	}
+	if formatted, ok := appendFormatted(buf, line); ok {
+		buf = formatted
+	} else {
+		buf = appendLine(buf, line.Fields(), line.Comment(), doc.padding, func(i int) int {
+			if !doc.Tabular {
+				return 0
+			}
+			mw, _ := doc.MaxColumnWidth(i)
+			return mw
+		})
+	}
+	buf = append(buf, byte('\n'))
+	if doc.writeBOM {
+		enc := make([]byte, 0, len(buf))
+		if doc.currentWriteLine == 0 {
+			enc = append(enc, doc.encoding.Preamble()...)
+		}
+		enc, err := reliabletxt.AppendText(enc, buf, doc.encoding)
 		if err != nil {
-			continue
+			return enc, err
 		}
+		buf = enc
+	}
+	doc.currentWriteLine += 1
+	return buf, nil
+}
+
+// appends the serialized fields of a line separated by the padding runes followed by the comment, if any.
+//
+// every field except the last one is padded with single spaces up to the column width returned by width
+func appendLine(buf []byte, fields []record.RecordField, comment string, padding []rune, width func(i int) int) []byte {
+	start := len(buf)
+	for i, field := range fields {
 		v := field.SerializeText()
-		p := utf8.RuneCountInString(v)
-		if doc.Tabular && (len(line.Fields())-1 != i) {
-			for {
-				// pad value with single spaces unless it's the last column or line has a comment
-				if p < mw {
-					v = fmt.Sprintf("%s%s", v, " ")
-					p = utf8.RuneCountInString(v)
-					continue
-				}
-				break
+		if i != len(fields)-1 {
+			// pad value with single spaces unless it's the last column
+			for range width(i) - utf8.RuneCountInString(v) {
+				v = v + " "
 			}
 		}
-
-		if i == 0 {
-			buf = append(buf, []byte(v)...)
-		} else {
-			buf = append(buf, utils.RuneToBytes(doc.padding)...)
-			buf = append(buf, []byte(v)...)
+		if i != 0 {
+			buf = append(buf, utils.RuneToBytes(padding)...)
 		}
+		buf = append(buf, []byte(v)...)
 	}
-	if len(line.Comment()) > 0 {
-		if len(buf) > 0 {
-			buf = append(buf, utils.RuneToBytes(doc.padding)...)
-			buf = append(buf, []byte(fmt.Sprintf("#%s", line.Comment()))...)
-		} else {
-			buf = append(buf, []byte(fmt.Sprintf("#%s", line.Comment()))...)
-
+	if len(comment) > 0 {
+		if len(buf) > start {
+			buf = append(buf, utils.RuneToBytes(padding)...)
 		}
+		buf = append(buf, []byte(fmt.Sprintf("#%s", comment))...)
 	}
-	buf = append(buf, byte('\n'))
-	doc.currentWriteLine += 1
-	return buf, nil
+	return buf
 }
 
 func (doc *Document) WriteAll() ([]byte, error) {
@@ -343,10 +457,48 @@ func (doc *Document) CalculateMaxFieldLengths() {
 	}
 }
 
+// calculates the column widths from scratch, CalculateMaxFieldLengths only ever grows them
+func (doc *Document) recalculateMaxFieldLengths() {
+	doc.maxColumnWidth = make(map[int]int)
+	doc.CalculateMaxFieldLengths()
+}
+
 func (doc *Document) HasHeaders() bool {
 	return doc.hasHeaders
 }
 
+// Sets whether the first line with values is the header line, documents have headers by default.
+// Once values are appended the header line is set and ErrHeaderLineSet is returned
+func (doc *Document) SetHasHeaders(v bool) error {
+	if doc.firstValuesLine() != nil {
+		return ErrHeaderLineSet
+	}
+	doc.hasHeaders = v
+	return nil
+}
+
+// returns the first line with values, or nil if no values were appended yet
+func (doc *Document) firstValuesLine() DocumentLine {
+	for _, line := range doc.lines {
+		if line.FieldCount() > 0 {
+			return line
+		}
+	}
+	return nil
+}
+
+// returns the number of fields of every line of a tabular document, the number of headers or without headers the
+// number of fields of the first line with values
+func (doc *Document) columnCount() int {
+	if doc.HasHeaders() {
+		return len(doc.headers)
+	}
+	if line := doc.firstValuesLine(); line != nil {
+		return line.FieldCount()
+	}
+	return 0
+}
+
 func (doc *Document) SetMaxColumnWidth(col int, len int) {
 	v, ok := doc.maxColumnWidth[col]
 	if !ok {
@@ -377,23 +529,18 @@ func (doc *Document) Headers() []string {
 	return doc.headers
 }
 
+// Renames the column at index fi, the fields of every line are renamed as well. Documents without headers are left as they are
 func (doc *Document) UpdateHeader(fi int, val string) error {
 	if !doc.HasHeaders() {
 		return nil
 	}
-
-	for i := range doc.LineCount() {
-		line, _ := doc.Line(i)
-		for fi := range line.FieldCount() {
-			if fi == 0 {
-				line.UpdateField(fi, val)
-			}
-			err := line.UpdateFieldName(fi, val)
-			if err != nil {
-				return err
-			}
-		}
+	if doc.startedWriting {
+		return &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	if fi < 0 || fi >= len(doc.headers) {
+		return ErrFieldIndexedNotFound
 	}
+	doc.renameColumn(fi, val)
 	return nil
 }
 
@@ -3,10 +3,8 @@ package document
 import (
 	"errors"
 	"fmt"
-	"strconv"
 
 	"github.com/internetcalifornia/wsv/v2/record"
-	"github.com/internetcalifornia/wsv/v2/utils"
 )
 
 var (
@@ -23,6 +21,10 @@ type documentLine struct {
 	line int
 	// count of data fields, has a getter documentLine.FieldCount()
 	fieldCount int
+	// the format the line is written with and the fields and comment it was set for
+	format           *LineFormat
+	formatted        []record.RecordField
+	formattedComment string
 }
 
 type DocumentLine interface {
@@ -74,6 +76,11 @@ type DocumentLine interface {
 	IsHeader() bool
 	// re-indexes line numbers back on order in the line slices
 	ReIndexLineNumber(i int)
+	// Set the format the line is written with, the fields and comment at the time of the call are the values the format
+	// was written for. Set nil to align the line with the rest of the document
+	SetFormat(f *LineFormat)
+	// Get the format the line is written with, nil if the line is aligned with the rest of the document
+	Format() *LineFormat
 }
 
 func (line *documentLine) IsHeader() bool {
@@ -115,6 +122,9 @@ func (line *documentLine) AppendValues(vals ...string) error {
 }
 
 func (line *documentLine) Append(val string) error {
+	if line.beforeHeader() {
+		return ErrLineBeforeHeader
+	}
 	field := record.RecordField{
 		Value: val,
 	}
@@ -129,7 +139,7 @@ func (line *documentLine) Append(val string) error {
 		return ErrFieldCount
 	}
 
-	if line.line > line.doc.headerLine && len(line.doc.Headers())-1 > fieldInd {
+	if line.line > line.doc.headerLine && len(line.doc.Headers()) > fieldInd {
 		field.FieldName = line.doc.Headers()[fieldInd]
 	}
 	field.FieldIndex = fieldInd
@@ -145,6 +155,9 @@ func (line *documentLine) Append(val string) error {
 }
 
 func (line *documentLine) AppendNull() error {
+	if line.beforeHeader() {
+		return ErrLineBeforeHeader
+	}
 	field := record.RecordField{IsNull: true}
 	if line.doc.HasHeaders() && (line.doc.headerLine == 0 || line.line == line.doc.headerLine) {
 		field.IsHeader = true
@@ -157,7 +170,7 @@ func (line *documentLine) AppendNull() error {
 		return ErrFieldCount
 	}
 
-	if line.line > line.doc.headerLine && len(line.doc.Headers())-1 > fieldInd {
+	if line.line > line.doc.headerLine && len(line.doc.Headers()) > fieldInd {
 		field.FieldName = line.doc.Headers()[fieldInd]
 	}
 	field.FieldIndex = fieldInd
@@ -172,7 +185,7 @@ func (line *documentLine) AppendNull() error {
 	return nil
 }
 
-// Compare compares the line with another line for sorting
+// Compare compares the line with another line for sorting, the values are compared with Auto
 // returns
 //
 // if:
@@ -180,6 +193,8 @@ func (line *documentLine) AppendNull() error {
 //	-1 when line[Field].Value < cmpLine[Field].Value or line[Field].Value is nil
 //	 0 when line[Field].Value == cmpLine[Field].Value
 //	+1 when line[Field].Value > cmpLine[Field].Value or cmpLine[Field].Value is nil
+//
+// the order is reversed when desc is true, a header line is always first
 func (line *documentLine) Compare(fieldName string, cmpLine DocumentLine, desc bool) int {
 	if line.IsHeader() {
 		return -1
@@ -187,48 +202,7 @@ func (line *documentLine) Compare(fieldName string, cmpLine DocumentLine, desc b
 	if cmpLine.IsHeader() {
 		return 1
 	}
-	a, err := line.FieldByName(fieldName)
-	if err != nil {
-		return -1
-	}
-	b, err := cmpLine.FieldByName(fieldName)
-	if err != nil {
-		return 1
-	}
-	if aint, err := strconv.ParseInt(a.Value, 10, utils.PtrSize()); err == nil {
-		if bint, err := strconv.ParseInt(b.Value, 10, utils.PtrSize()); err == nil {
-			if aint < bint {
-				if desc {
-					return 1
-				}
-				return -1
-			}
-			if aint > bint {
-				if desc {
-					return -1
-				}
-				return 1
-			}
-			if aint == bint {
-				return 0
-			}
-		}
-		return 1
-	}
-	if a.Value < b.Value {
-		if desc {
-			return 1
-		}
-		return -1
-	}
-	if a.Value > b.Value {
-		if desc {
-			return -1
-		}
-		return 1
-	}
-
-	return 0
+	return SortOption{FieldName: fieldName, Desc: desc}.compare(line, cmpLine)
 }
 
 func (line *documentLine) NextField() (*record.RecordField, error) {
@@ -260,6 +234,7 @@ func (line *documentLine) UpdateField(fieldInd int, val string) error {
 	}
 	field := line.fields[fieldInd]
 	field.Value = val
+	field.IsNull = false
 	line.fields[fieldInd] = field
 	fw := field.CalculateFieldLength()
 	line.doc.SetMaxColumnWidth(fieldInd, fw)
@@ -274,12 +249,25 @@ func (line *documentLine) Comment() string {
 	return line.comment
 }
 
+// whether the line comes before the header line, such lines can only hold a comment
+func (line *documentLine) beforeHeader() bool {
+	return line.doc.HasHeaders() && line.doc.headerLine != 0 && line.line < line.doc.headerLine
+}
+
 // check field index is valid, returns the number of fields left, -1 is returned when document is not tabular or is the first line
 func (line *documentLine) checkFieldIndex(fieldInd int) error {
 	if !line.doc.Tabular || line.line == line.doc.headerLine {
 		// if the document is not tabular or this is the first line this check won't be in effect
 		return nil
 	}
+	if !line.doc.HasHeaders() {
+		// without headers the first line with values sets the number of fields
+		first := line.doc.firstValuesLine()
+		if first == nil || first == DocumentLine(line) || first.FieldCount() > fieldInd {
+			return nil
+		}
+		return &WriteError{err: ErrFieldCount, line: line.line, fieldIndex: fieldInd}
+	}
 	if line.doc.LineCount() <= 1 {
 		// unexpected
 		return ErrNotEnoughLines
@@ -1,10 +1,14 @@
 package document
 
 import (
+	"errors"
 	"io"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+	"github.com/internetcalifornia/wsv/v2/schema"
 )
 
 func TestCreateTabularDocument(t *testing.T) {
@@ -375,3 +379,122 @@ func TestSorting(t *testing.T) {
 		t.Error("did not sort the expected way")
 	}
 }
+
+func TestDocumentAll(t *testing.T) {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age")...)
+	doc.AppendLine(Fields("Scott", "33")...)
+	doc.AppendLine(Fields("Jane", "21")...)
+	n := 0
+	for ln, line := range doc.All() {
+		n++
+		if ln != line.LineNumber() {
+			t.Errorf("expected line number %d but got %d", line.LineNumber(), ln)
+		}
+		if ln == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Error("expected iteration to stop after 2 lines but got", n)
+	}
+}
+
+func TestWriteWithEncoding(t *testing.T) {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age")...)
+	doc.AppendLine(Fields("Zoë", "33")...)
+	plain, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	doc.ResetWrite()
+	err = doc.SetEncoding(reliabletxt.UTF16LE)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	text, enc, err := reliabletxt.Decode(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if enc != reliabletxt.UTF16LE || string(text) != string(plain) {
+		t.Errorf("expected %q encoded as UTF-16LE but got %s %q", string(plain), enc, string(text))
+	}
+}
+
+func TestFieldNameOfLastColumn(t *testing.T) {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age")...)
+	line, _ := doc.AppendLine(Field("Scott"), Field("33"))
+	if f, err := line.FieldByName("Age"); err != nil || f.Value != "33" {
+		t.Errorf("expected the last field to be named Age but got %v %v", f, err)
+	}
+	line, _ = doc.AppendLine(Field("Jane"), Null())
+	if f, err := line.FieldByName("Age"); err != nil || !f.IsNull {
+		t.Errorf("expected the last null field to be named Age but got %v %v", f, err)
+	}
+}
+
+func TestWriteSchema(t *testing.T) {
+	doc := NewDocument()
+	doc.SetSchema(schema.New(schema.Column{Name: "Name"}, schema.Column{Name: "Age", Type: schema.Int, Nullable: true}))
+	doc.AppendLine(Fields("Name", "Age")...)
+	doc.AppendLine(Field("Scott"), Null())
+	b, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if exp := "#@schema Name:string Age:int?\nName   Age\nScott  -\n"; string(b) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, b)
+	}
+}
+
+func TestSetHasHeaders(t *testing.T) {
+	doc := NewDocument()
+	if err := doc.SetHasHeaders(false); err != nil {
+		t.Error(err)
+	}
+	doc.AppendLine(Fields("Scott", "33")...)
+	doc.AppendLine(Fields("Bartholomew", "41")...)
+	if _, err := doc.AppendLine(Fields("Jane", "21", "Chicago")...); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("expected the first line to set the number of fields but got %v", err)
+	}
+	doc.DeleteLine(3)
+	d, err := doc.WriteAll()
+	if exp := "Scott        33\nBartholomew  41\n"; err != nil || string(d) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s %v", exp, d, err)
+	}
+	if err := doc.SetHasHeaders(true); !errors.Is(err, ErrHeaderLineSet) {
+		t.Errorf("expected ErrHeaderLineSet but got %v", err)
+	}
+}
+
+func TestUpdateNullField(t *testing.T) {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age")...)
+	line, _ := doc.AppendLine(Field("Scott"), Null())
+	if err := line.UpdateField(1, "33"); err != nil {
+		t.Error(err)
+		return
+	}
+	if f, _ := line.Field(1); f.IsNull || f.Value != "33" {
+		t.Errorf("expected the updated field not to be null but got %+v", f)
+	}
+	b, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if exp := "Name   Age\nScott  33\n"; string(b) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, b)
+	}
+}
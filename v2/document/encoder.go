@@ -0,0 +1,110 @@
+package document
+
+import (
+	"io"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+	"github.com/internetcalifornia/wsv/v2/utils"
+)
+
+// An Encoder writes lines directly to an io.Writer without holding the whole document in memory.
+//
+// By default lines are written unaligned as soon as they are encoded, with the padding runes written once between fields.
+// With SetWindow lines are buffered in chunks and the columns are aligned within each chunk.
+type Encoder struct {
+	w       io.Writer
+	padding []rune
+	window  int
+	pending []encoderLine
+	line    int
+}
+
+type encoderLine struct {
+	fields  []record.RecordField
+	comment string
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+		// The runes in between data values
+		padding: []rune{' '},
+		pending: make([]encoderLine, 0),
+	}
+}
+
+func (enc *Encoder) SetPadding(rs []rune) error {
+	for _, r := range rs {
+		if !utils.IsFieldDelimiter(r) {
+			return &WriteError{err: ErrInvalidPaddingRune}
+		}
+	}
+	enc.padding = rs
+	return nil
+}
+
+// Aligns columns within chunks of n lines. Lines are buffered until n lines have been encoded or Flush is called.
+//
+// A window of 0 or less disables alignment and lines are written as they are encoded, which is the default.
+func (enc *Encoder) SetWindow(n int) {
+	enc.window = n
+}
+
+// Encodes a line of fields, use Field(val) and Null() to build the fields
+func (enc *Encoder) Encode(fields ...appendLineField) error {
+	return enc.EncodeWithComment("", fields...)
+}
+
+// Encodes a line of fields followed by a comment, a line without fields only contains the comment
+func (enc *Encoder) EncodeWithComment(comment string, fields ...appendLineField) error {
+	enc.line++
+	line := encoderLine{
+		fields:  make([]record.RecordField, len(fields)),
+		comment: comment,
+	}
+	for i, f := range fields {
+		line.fields[i] = record.RecordField{Value: f.val, IsNull: f.isNull, FieldIndex: i, RowIndex: enc.line}
+	}
+	if enc.window <= 0 {
+		return enc.writeLines([]encoderLine{line}, func(i int) int { return 0 })
+	}
+	enc.pending = append(enc.pending, line)
+	if len(enc.pending) >= enc.window {
+		return enc.Flush()
+	}
+	return nil
+}
+
+// Encodes the values as a line, unlike Document.AppendValues the literal "-" is not interpreted as null
+func (enc *Encoder) EncodeValues(vals ...string) error {
+	return enc.Encode(Fields(vals...)...)
+}
+
+// Writes any buffered lines aligned to the widest value of each column in the buffer
+func (enc *Encoder) Flush() error {
+	if len(enc.pending) == 0 {
+		return nil
+	}
+	widths := make(map[int]int)
+	for _, line := range enc.pending {
+		for i, field := range line.fields {
+			fw := field.CalculateFieldLength()
+			if widths[i] < fw {
+				widths[i] = fw
+			}
+		}
+	}
+	err := enc.writeLines(enc.pending, func(i int) int { return widths[i] })
+	enc.pending = enc.pending[:0]
+	return err
+}
+
+func (enc *Encoder) writeLines(lines []encoderLine, width func(i int) int) error {
+	buf := make([]byte, 0)
+	for _, line := range lines {
+		buf = appendLine(buf, line.fields, line.comment, enc.padding, width)
+		buf = append(buf, byte('\n'))
+	}
+	_, err := enc.w.Write(buf)
+	return err
+}
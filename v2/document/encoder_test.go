@@ -0,0 +1,64 @@
+package document
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoderUnaligned(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	err := enc.EncodeValues("Name", "Age", "Favorite Color")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = enc.EncodeWithComment("cool person", Field("Scott"), Null(), Field("red"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = enc.EncodeWithComment("only a comment")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := `Name Age "Favorite Color"` + "\n" +
+		`Scott - red #cool person` + "\n" +
+		`#only a comment` + "\n"
+	if buf.String() != exp {
+		t.Errorf("expected output to be \n%s\nbut got \n%s\ninstead", exp, buf.String())
+	}
+}
+
+func TestEncoderWindow(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetWindow(2)
+	err := enc.SetPadding([]rune{' ', ' '})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	enc.EncodeValues("Name", "Age", "Color")
+	enc.EncodeValues("Scott", "33", "red")
+	if buf.Len() == 0 {
+		t.Error("expected the first window to be written")
+	}
+	enc.EncodeValues("Patrick", "100", "blue")
+	err = enc.Flush()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := `Name   Age  Color` + "\n" +
+		`Scott  33   red` + "\n" +
+		`Patrick  100  blue` + "\n"
+	if buf.String() != exp {
+		t.Errorf("expected output to be \n%s\nbut got \n%s\ninstead", exp, buf.String())
+	}
+	err = enc.SetPadding([]rune{'x'})
+	if err == nil {
+		t.Error("expected an error for a non whitespace padding rune")
+	}
+}
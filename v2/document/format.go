@@ -0,0 +1,84 @@
+package document
+
+import (
+	"unicode/utf8"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+// The whitespace and the text of the fields of a line as they were read, a line with a format is written back as it was
+// instead of being aligned with the rest of the document.
+//
+// Fields whose value changed since the format was set are serialized again and the whitespace after them shrinks or grows
+// by the change in width, so the following columns stay in place where possible.
+type LineFormat struct {
+	// the whitespace before each field, the last entry is the whitespace before the comment or at the end of the line
+	Whitespace []string
+	// the text of each field as written, including its quotes
+	Fields []string
+}
+
+func (line *documentLine) SetFormat(f *LineFormat) {
+	line.format = f
+	line.formatted = nil
+	line.formattedComment = ""
+	if f != nil {
+		line.formatted = append([]record.RecordField(nil), line.fields...)
+		line.formattedComment = line.comment
+	}
+}
+
+func (line *documentLine) Format() *LineFormat {
+	return line.format
+}
+
+// appends the line with its format, ok is false when the line has no format or the fields no longer match it
+func appendFormatted(buf []byte, l DocumentLine) (_ []byte, ok bool) {
+	line, isLine := l.(*documentLine)
+	if !isLine || line.format == nil {
+		return buf, false
+	}
+	f := line.format
+	if len(f.Fields) != len(line.fields) || len(f.Whitespace) != len(f.Fields)+1 || len(line.formatted) != len(line.fields) {
+		return buf, false
+	}
+	// the change in width of the previous field
+	shift := 0
+	for i, field := range line.fields {
+		buf = appendWhitespace(buf, f.Whitespace[i], shift)
+		text := f.Fields[i]
+		if prev := line.formatted[i]; field.IsNull != prev.IsNull || field.Value != prev.Value {
+			text = field.SerializeText()
+		}
+		shift = utf8.RuneCountInString(text) - utf8.RuneCountInString(f.Fields[i])
+		buf = append(buf, text...)
+	}
+	last := f.Whitespace[len(f.Fields)]
+	if line.comment == "" {
+		// trailing whitespace is kept, the whitespace before a removed comment is not
+		if line.formattedComment == "" {
+			buf = append(buf, last...)
+		}
+		return buf, true
+	}
+	if line.formattedComment == "" && len(line.fields) > 0 && last == "" {
+		last = " "
+	}
+	buf = appendWhitespace(buf, last, shift)
+	buf = append(buf, '#')
+	buf = append(buf, line.comment...)
+	return buf, true
+}
+
+// appends the whitespace, removing up to shift trailing spaces while keeping at least one rune, or adding -shift spaces
+func appendWhitespace(buf []byte, ws string, shift int) []byte {
+	for shift > 0 && len(ws) > 1 && ws[len(ws)-1] == ' ' {
+		ws = ws[:len(ws)-1]
+		shift--
+	}
+	buf = append(buf, ws...)
+	for ; shift < 0; shift++ {
+		buf = append(buf, ' ')
+	}
+	return buf
+}
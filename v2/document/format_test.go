@@ -0,0 +1,37 @@
+package document
+
+import "testing"
+
+func formatDocument() *Document {
+	doc := NewDocument()
+	doc.AppendLine(Fields("Name", "Age")...)
+	line, _ := doc.AppendLine(Fields("Bartholomew", "41")...)
+	line.UpdateComment("uncle")
+	line.SetFormat(&LineFormat{Whitespace: []string{"", "   ", "\t"}, Fields: []string{"Bartholomew", `"41"`}})
+	return doc
+}
+
+func TestFormat(t *testing.T) {
+	doc := formatDocument()
+	expectDocument(t, "format", doc, "Name         Age\nBartholomew   \"41\"\t#uncle\n")
+
+	doc = formatDocument()
+	line, _ := doc.Line(2)
+	line.UpdateField(0, "Bo")
+	line.UpdateComment("")
+	expectDocument(t, "edited", doc, "Name         Age\nBo            \"41\"\n")
+
+	doc = formatDocument()
+	line, _ = doc.Line(2)
+	line.SetFormat(nil)
+	if line.Format() != nil {
+		t.Errorf("expected the format to be removed")
+	}
+	expectDocument(t, "no format", doc, "Name         Age\nBartholomew  41  #uncle\n")
+
+	doc = formatDocument()
+	if err := doc.InsertColumn(2, "City", nil); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "new column", doc, "Name         Age  City\nBartholomew  41   -  #uncle\n")
+}
@@ -0,0 +1,132 @@
+package document
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+var (
+	ErrDeleteHeader     = errors.New("the header line cannot be deleted while data lines follow it")
+	ErrLineBeforeHeader = errors.New("a line with values cannot come before the header line")
+)
+
+// Inserts an empty line so it becomes line n, the lines from n on move down by one. Use n = doc.LineCount()+1 to add
+// the line at the end. A line inserted before the header line can only hold a comment, appending values to it returns
+// ErrLineBeforeHeader.
+//
+// returns the line that was added to append values to
+func (doc *Document) InsertLineAt(n int) (DocumentLine, error) {
+	if doc.startedWriting {
+		return nil, &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	if n < 1 || n > len(doc.lines)+1 {
+		return nil, ErrLineNotFound
+	}
+	line := &documentLine{
+		doc:    doc,
+		fields: make([]record.RecordField, 0),
+		line:   n,
+	}
+	doc.editLines(func() {
+		doc.lines = slices.Insert(doc.lines, n-1, DocumentLine(line))
+	})
+	return line, nil
+}
+
+// Deletes line n, the lines after it move up by one. The header line can only be deleted when it is the last line with fields.
+func (doc *Document) DeleteLine(n int) error {
+	if doc.startedWriting {
+		return &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	if n < 1 || n > len(doc.lines) {
+		return ErrLineNotFound
+	}
+	if doc.HasHeaders() && n == doc.headerLine {
+		for _, line := range doc.lines[n:] {
+			if line.FieldCount() > 0 {
+				return ErrDeleteHeader
+			}
+		}
+	}
+	doc.editLines(func() {
+		doc.lines = slices.Delete(doc.lines, n-1, n)
+	})
+	return nil
+}
+
+// Deletes every line del returns true for, the header line is never deleted.
+//
+// returns the number of lines deleted
+func (doc *Document) DeleteLines(del func(DocumentLine) bool) (int, error) {
+	if doc.startedWriting {
+		return 0, &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	n := len(doc.lines)
+	doc.editLines(func() {
+		doc.lines = slices.DeleteFunc(doc.lines, func(line DocumentLine) bool {
+			return !line.IsHeader() && del(line)
+		})
+	})
+	return n - len(doc.lines), nil
+}
+
+// Moves line from so it becomes line to, the lines in between shift by one to make room. A line with values cannot move
+// before the header line and the header line cannot move after a line with values, ErrLineBeforeHeader is returned
+// instead
+func (doc *Document) MoveLine(from int, to int) error {
+	if doc.startedWriting {
+		return &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	if from < 1 || from > len(doc.lines) || to < 1 || to > len(doc.lines) {
+		return ErrLineNotFound
+	}
+	line := doc.lines[from-1]
+	lines := slices.Insert(slices.Delete(slices.Clone(doc.lines), from-1, from), to-1, line)
+	if doc.HasHeaders() && doc.headerLine > 0 && doc.headerLine <= len(doc.lines) {
+		header := slices.Index(lines, doc.lines[doc.headerLine-1])
+		for _, l := range lines[:header] {
+			if l.FieldCount() > 0 {
+				return ErrLineBeforeHeader
+			}
+		}
+	}
+	doc.editLines(func() {
+		doc.lines = lines
+	})
+	return nil
+}
+
+// Keeps the first n lines of the document and deletes the rest. When the header line is deleted the headers are cleared
+// and the next line values are appended to becomes the header line
+func (doc *Document) Truncate(n int) error {
+	if doc.startedWriting {
+		return &WriteError{err: ErrStartedToWrite, line: doc.currentWriteLine}
+	}
+	if n < 0 || n > len(doc.lines) {
+		return ErrLineNotFound
+	}
+	doc.editLines(func() {
+		doc.lines = slices.Delete(doc.lines, n, len(doc.lines))
+	})
+	return nil
+}
+
+// runs edit on the lines of the document, then re-indexes the line numbers, finds the header line at its new position
+// and recalculates the column widths
+func (doc *Document) editLines(edit func()) {
+	var header DocumentLine
+	if doc.headerLine > 0 && doc.headerLine <= len(doc.lines) {
+		header = doc.lines[doc.headerLine-1]
+	}
+	edit()
+	doc.ReIndexLineNumbers()
+	doc.headerLine = 0
+	if i := slices.Index(doc.lines, header); header != nil && i >= 0 {
+		doc.headerLine = i + 1
+	} else if header != nil {
+		doc.headers = make([]string, 0)
+	}
+	doc.recalculateMaxFieldLengths()
+}
@@ -0,0 +1,147 @@
+package document
+
+import (
+	"errors"
+	"testing"
+)
+
+func lineDocument() *Document {
+	doc := NewDocument()
+	doc.AddLine()
+	line, _ := doc.Line(1)
+	line.UpdateComment("people")
+	doc.AppendLine(Fields("Name", "Age")...)
+	doc.AppendLine(Fields("Scott", "33")...)
+	doc.AppendLine(Fields("Bartholomew", "41")...)
+	doc.AppendLine(Fields("Jane", "21")...)
+	return doc
+}
+
+func TestInsertLineAt(t *testing.T) {
+	doc := lineDocument()
+	line, err := doc.InsertLineAt(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	line.UpdateComment("first")
+	line, err = doc.InsertLineAt(4)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := line.AppendValues("Ann", "5"); err != nil {
+		t.Error(err)
+	}
+	if f, _ := line.FieldByName("Age"); f == nil || f.Value != "5" {
+		t.Errorf("expected the inserted line to have the header names but got %+v", f)
+	}
+	if _, err := doc.InsertLineAt(9); !errors.Is(err, ErrLineNotFound) {
+		t.Errorf("expected ErrLineNotFound but got %v", err)
+	}
+	if _, err := doc.InsertLineAt(doc.LineCount() + 1); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "insert", doc, "#first\n#people\nName         Age\nAnn          5\nScott        33\nBartholomew  41\nJane         21\n\n")
+}
+
+func TestDeleteLine(t *testing.T) {
+	doc := lineDocument()
+	if err := doc.DeleteLine(4); err != nil {
+		t.Error(err)
+	}
+	if err := doc.DeleteLine(1); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "delete", doc, "Name   Age\nScott  33\nJane   21\n")
+	if line, _ := doc.Line(1); !line.IsHeader() {
+		t.Errorf("expected line 1 to be the header line")
+	}
+	doc.ResetWrite()
+	if err := doc.DeleteLine(1); !errors.Is(err, ErrDeleteHeader) {
+		t.Errorf("expected ErrDeleteHeader but got %v", err)
+	}
+	if err := doc.DeleteLine(4); !errors.Is(err, ErrLineNotFound) {
+		t.Errorf("expected ErrLineNotFound but got %v", err)
+	}
+
+	doc = lineDocument()
+	n, err := doc.DeleteLines(func(line DocumentLine) bool {
+		f, err := line.FieldByName("Age")
+		return err != nil || f.Value > "30"
+	})
+	if err != nil || n != 3 {
+		t.Errorf("expected 3 lines to be deleted but got %d %v", n, err)
+	}
+	expectDocument(t, "delete lines", doc, "Name  Age\nJane  21\n")
+}
+
+func TestMoveLine(t *testing.T) {
+	doc := lineDocument()
+	if err := doc.MoveLine(5, 3); err != nil {
+		t.Error(err)
+	}
+	if err := doc.MoveLine(1, 2); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "move", doc, "Name         Age\n#people\nJane         21\nScott        33\nBartholomew  41\n")
+	if line, _ := doc.Line(1); !line.IsHeader() || line.LineNumber() != 1 {
+		t.Errorf("expected the header line to move to line 1")
+	}
+	doc.ResetWrite()
+	if err := doc.MoveLine(0, 2); !errors.Is(err, ErrLineNotFound) {
+		t.Errorf("expected ErrLineNotFound but got %v", err)
+	}
+}
+
+func TestMoveLineBeforeHeader(t *testing.T) {
+	doc := lineDocument()
+	if err := doc.MoveLine(3, 1); !errors.Is(err, ErrLineBeforeHeader) {
+		t.Errorf("expected ErrLineBeforeHeader moving a data line before the header but got %v", err)
+	}
+	if err := doc.MoveLine(2, 4); !errors.Is(err, ErrLineBeforeHeader) {
+		t.Errorf("expected ErrLineBeforeHeader moving the header after a data line but got %v", err)
+	}
+	expectDocument(t, "unchanged", doc, "#people\nName         Age\nScott        33\nBartholomew  41\nJane         21\n")
+}
+
+func TestInsertLineBeforeHeader(t *testing.T) {
+	doc := lineDocument()
+	line, err := doc.InsertLineAt(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := line.Append("Ann"); !errors.Is(err, ErrLineBeforeHeader) {
+		t.Errorf("expected ErrLineBeforeHeader but got %v", err)
+	}
+	if err := line.AppendNull(); !errors.Is(err, ErrLineBeforeHeader) {
+		t.Errorf("expected ErrLineBeforeHeader but got %v", err)
+	}
+	line.UpdateComment("comment")
+	expectDocument(t, "insert", doc, "#people\n#comment\nName         Age\nScott        33\nBartholomew  41\nJane         21\n")
+}
+
+func TestTruncate(t *testing.T) {
+	doc := lineDocument()
+	if err := doc.Truncate(3); err != nil {
+		t.Error(err)
+	}
+	expectDocument(t, "truncate", doc, "#people\nName   Age\nScott  33\n")
+
+	doc.ResetWrite()
+	if err := doc.Truncate(1); err != nil {
+		t.Error(err)
+	}
+	if len(doc.Headers()) != 0 || doc.headerLine != 0 {
+		t.Errorf("expected the headers to be cleared but got %q", doc.Headers())
+	}
+	doc.AppendLine(Fields("Country", "Capital")...)
+	doc.AppendLine(Fields("Japan", "Tokyo")...)
+	expectDocument(t, "new header", doc, "#people\nCountry  Capital\nJapan    Tokyo\n")
+
+	doc.Write()
+	if err := doc.Truncate(0); !errors.As(err, new(*WriteError)) {
+		t.Errorf("expected a WriteError after writing started but got %v", err)
+	}
+}
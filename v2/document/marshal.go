@@ -0,0 +1,167 @@
+package document
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/internetcalifornia/wsv/v2/utils"
+)
+
+var (
+	ErrInvalidMarshal  = errors.New("marshal value must be a struct, a pointer to a struct or a slice of structs")
+	ErrUnsupportedType = errors.New("unsupported field type")
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// Marshal returns the WSV encoding of v, a struct or a slice of structs.
+//
+// The header line is built from the `wsv` struct tags followed by one data line per element, an empty slice only writes
+// the header line, see Document.AppendStruct
+func Marshal(v any) ([]byte, error) {
+	doc := NewDocument()
+	err := doc.AppendStruct(v)
+	if err != nil {
+		return nil, err
+	}
+	return doc.WriteAll()
+}
+
+// Appends one line per struct in v, v can be a struct, a pointer to a struct or a slice of either.
+//
+// If the document does not have a header line yet one is added using the column names from the `wsv` struct tags,
+// otherwise values are placed under the existing header with the same name and missing columns are null.
+// Nil pointers are appended as null values.
+func (doc *Document) AppendStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ErrInvalidMarshal
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		return doc.appendStruct(rv)
+	case reflect.Slice, reflect.Array:
+		// the header comes from the element type so an empty slice still writes it
+		elemType := rv.Type().Elem()
+		for elemType.Kind() == reflect.Pointer {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() == reflect.Struct {
+			if err := doc.appendHeader(utils.TaggedFields(elemType)); err != nil {
+				return err
+			}
+		}
+		for i := range rv.Len() {
+			elem := rv.Index(i)
+			for elem.Kind() == reflect.Pointer {
+				if elem.IsNil() {
+					return ErrInvalidMarshal
+				}
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				return ErrInvalidMarshal
+			}
+			err := doc.appendStruct(elem)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ErrInvalidMarshal
+	}
+}
+
+func (doc *Document) appendStruct(rv reflect.Value) error {
+	fields := utils.TaggedFields(rv.Type())
+	if err := doc.appendHeader(fields); err != nil {
+		return err
+	}
+
+	values := make([]appendLineField, len(fields))
+	for i, tf := range fields {
+		val, isNull, err := formatValue(rv.Field(tf.Index), tf.Layout)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", tf.Name, err)
+		}
+		values[i] = appendLineField{val: val, isNull: isNull}
+	}
+
+	if !doc.HasHeaders() {
+		_, err := doc.AppendLine(values...)
+		return err
+	}
+
+	// place the values under the matching header, columns without a struct field are null
+	line := make([]appendLineField, len(doc.headers))
+	for i, h := range doc.headers {
+		line[i] = Null()
+		for fi, tf := range fields {
+			if tf.Column == h {
+				line[i] = values[fi]
+				break
+			}
+		}
+	}
+	_, err := doc.AppendLine(line...)
+	return err
+}
+
+// appends the header line from the column names of the fields, unless the document already has one
+func (doc *Document) appendHeader(fields []utils.TaggedField) error {
+	if !doc.HasHeaders() || doc.headerLine != 0 {
+		return nil
+	}
+	header := make([]appendLineField, len(fields))
+	for i, tf := range fields {
+		header[i] = Field(tf.Column)
+	}
+	_, err := doc.AppendLine(header...)
+	return err
+}
+
+func formatValue(v reflect.Value, layout string) (string, bool, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", true, nil
+		}
+		return formatValue(v.Elem(), layout)
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(layout), false, nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), false, err
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), false, err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), false, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), false, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), false, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), false, nil
+	default:
+		return "", false, fmt.Errorf("%w %s", ErrUnsupportedType, v.Type())
+	}
+}
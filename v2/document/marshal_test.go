@@ -0,0 +1,80 @@
+package document
+
+import (
+	"testing"
+	"time"
+)
+
+type employee struct {
+	Name     string    `wsv:"Name"`
+	Age      *int      `wsv:"Age"`
+	Salary   float64   `wsv:"Salary"`
+	Active   bool      `wsv:"Active"`
+	HireDate time.Time `wsv:"Hire Date,layout=2006-01-02"`
+	internal string
+	Skip     string `wsv:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	age := 33
+	employees := []employee{
+		{Name: "Scott", Age: &age, Salary: 1250.5, Active: true, HireDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Skip: "skip"},
+		{Name: "Jane Smith", Salary: 900, HireDate: time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	b, err := Marshal(employees)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := `Name          Age  Salary  Active  "Hire Date"` + "\n" +
+		`Scott         33   1250.5  true    "2023-01-02"` + "\n" +
+		`"Jane Smith"  -    900     false   "2024-02-03"` + "\n"
+	if string(b) != exp {
+		t.Errorf("expected output to be \n%s\nbut got \n%s\ninstead", exp, string(b))
+	}
+}
+
+func TestMarshalEmptySlice(t *testing.T) {
+	for _, v := range []any{[]employee{}, []*employee(nil)} {
+		b, err := Marshal(v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if exp := `Name  Age  Salary  Active  "Hire Date"` + "\n"; string(b) != exp {
+			t.Errorf("expected only the header line\n%s\nbut got \n%s", exp, string(b))
+		}
+	}
+}
+
+func TestAppendStructToExistingHeader(t *testing.T) {
+	doc := NewDocument()
+	_, err := doc.AppendLine(Fields("Active", "Name", "Department")...)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = doc.AppendStruct(&employee{Name: "Scott", Active: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	line, err := doc.Line(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if f, err := line.Field(0); err != nil || f.Value != "true" {
+		t.Errorf("expected Active to be true but got %+v", f)
+	}
+	if f, err := line.Field(1); err != nil || f.Value != "Scott" {
+		t.Errorf("expected Name to be Scott but got %+v", f)
+	}
+	if f, err := line.Field(2); err != nil || !f.IsNull {
+		t.Errorf("expected Department to be null but got %+v", f)
+	}
+	err = doc.AppendStruct(42)
+	if err != ErrInvalidMarshal {
+		t.Errorf("expected ErrInvalidMarshal but got %v", err)
+	}
+}
@@ -0,0 +1,468 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	// a keyword, column name or file name
+	tokIdent
+	// a double quoted column or file name
+	tokQuoted
+	// a single quoted string
+	tokString
+	tokNumber
+	// punctuation and comparison operators
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var symbols = []string{"<=", ">=", "<>", "!=", "=", "<", ">", ",", "(", ")", "*"}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_./-~", r)
+}
+
+func lex(q string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(q); {
+		r, size := utf8.DecodeRuneInString(q[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '\'' || r == '"':
+			text, n, err := lexQuoted(q[i:], byte(r))
+			if err != nil {
+				return nil, syntaxError(i, err.Error())
+			}
+			kind := tokString
+			if r == '"' {
+				kind = tokQuoted
+			}
+			tokens = append(tokens, token{kind, text, i})
+			i += n
+		case unicode.IsDigit(r) || (r == '-' || r == '+') && i+1 < len(q) && q[i+1] >= '0' && q[i+1] <= '9':
+			start := i
+			i += size
+			for i < len(q) && strings.ContainsRune("0123456789.eE", rune(q[i])) {
+				i++
+			}
+			text := q[start:i]
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, syntaxError(start, fmt.Sprintf("invalid number %q", text))
+			}
+			tokens = append(tokens, token{tokNumber, text, start})
+		case isIdentRune(r):
+			start := i
+			for i < len(q) {
+				r, size := utf8.DecodeRuneInString(q[i:])
+				if !isIdentRune(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{tokIdent, q[start:i], start})
+		default:
+			matched := false
+			for _, s := range symbols {
+				if strings.HasPrefix(q[i:], s) {
+					tokens = append(tokens, token{tokSymbol, s, i})
+					i += len(s)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, syntaxError(i, fmt.Sprintf("unexpected %q", r))
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(q)}), nil
+}
+
+// returns the text between the quotes at the start of q, a doubled quote is a literal quote, and the number of bytes consumed
+func lexQuoted(q string, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(q); i++ {
+		if q[i] != quote {
+			b.WriteByte(q[i])
+			continue
+		}
+		if i+1 < len(q) && q[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated %c", quote)
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	// every column referenced by the query
+	columns []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+// whether the next token is the keyword, keywords are case insensitive
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+// consumes the keyword if it is next
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.unexpected(kw)
+	}
+	return nil
+}
+
+func (p *parser) acceptSymbol(s string) bool {
+	t := p.peek()
+	if t.kind == tokSymbol && t.text == s {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(s string) error {
+	if !p.acceptSymbol(s) {
+		return p.unexpected(fmt.Sprintf("%q", s))
+	}
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return syntaxError(t.pos, fmt.Sprintf("unexpected end of query, expected %s", expected))
+	}
+	return syntaxError(t.pos, fmt.Sprintf("expected %s but got %q", expected, t.text))
+}
+
+var keywords = []string{"SELECT", "FROM", "WHERE", "ORDER", "BY", "ASC", "DESC", "LIMIT", "AND", "OR", "NOT", "IN", "IS", "NULL", "BETWEEN", "LIKE"}
+
+// parses a column name, which is an identifier that is not a keyword or a double quoted name
+func (p *parser) column() (string, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokQuoted:
+	case tokIdent:
+		for _, kw := range keywords {
+			if strings.EqualFold(t.text, kw) {
+				return "", p.unexpected("a column name")
+			}
+		}
+	default:
+		return "", p.unexpected("a column name")
+	}
+	p.i++
+	p.columns = append(p.columns, t.text)
+	return t.text, nil
+}
+
+// Parses a query of the form
+//
+//	SELECT * | column, ... FROM file [WHERE condition] [ORDER BY column [ASC | DESC], ...] [LIMIT n]
+//
+// Keywords are case insensitive, column and file names with spaces or keywords are double quoted and strings are single quoted.
+// A condition compares a column to a value with =, !=, <>, <, <=, > or >=, or is one of
+//
+//	column IS [NOT] NULL
+//	column [NOT] IN (value, ...)
+//	column [NOT] BETWEEN number AND number
+//	column [NOT] LIKE 'pattern'
+//
+// combined with AND, OR, NOT and parentheses. Comparisons with a number are numeric. A null only matches IS NULL, every
+// other condition leaves it out whether it is negated or not.
+func Parse(q string) (*Query, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	query := &Query{Limit: -1}
+
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if !p.acceptSymbol("*") {
+		for {
+			c, err := p.column()
+			if err != nil {
+				return nil, err
+			}
+			query.Columns = append(query.Columns, c)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from := p.peek()
+	if from.kind != tokIdent && from.kind != tokQuoted {
+		return nil, p.unexpected("a file name")
+	}
+	p.i++
+	query.From = from.text
+
+	if p.acceptKeyword("WHERE") {
+		query.Where, err = p.or(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			c, err := p.column()
+			if err != nil {
+				return nil, err
+			}
+			desc := p.acceptKeyword("DESC")
+			if !desc {
+				p.acceptKeyword("ASC")
+			}
+			query.OrderBy = append(query.OrderBy, Order{Column: c, Desc: desc})
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		t := p.peek()
+		n, err := strconv.Atoi(t.text)
+		if t.kind != tokNumber || err != nil || n < 0 {
+			return nil, p.unexpected("a limit")
+		}
+		p.i++
+		query.Limit = n
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.unexpected("the end of the query")
+	}
+	query.referenced = p.columns
+	return query, nil
+}
+
+// NOT is pushed down to the conditions with De Morgan's laws, so a negated condition can leave out the nulls of its
+// column like every comparison does
+
+func (p *parser) or(negate bool) (reader.Predicate, error) {
+	preds := make([]reader.Predicate, 0, 1)
+	for {
+		pred, err := p.and(negate)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+		if !p.acceptKeyword("OR") {
+			break
+		}
+	}
+	if len(preds) == 1 {
+		return preds[0], nil
+	}
+	if negate {
+		return reader.And(preds...), nil
+	}
+	return reader.Or(preds...), nil
+}
+
+func (p *parser) and(negate bool) (reader.Predicate, error) {
+	preds := make([]reader.Predicate, 0, 1)
+	for {
+		pred, err := p.not(negate)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+		if !p.acceptKeyword("AND") {
+			break
+		}
+	}
+	if len(preds) == 1 {
+		return preds[0], nil
+	}
+	if negate {
+		return reader.Or(preds...), nil
+	}
+	return reader.And(preds...), nil
+}
+
+func (p *parser) not(negate bool) (reader.Predicate, error) {
+	if p.acceptKeyword("NOT") {
+		return p.not(!negate)
+	}
+	if p.acceptSymbol("(") {
+		pred, err := p.or(negate)
+		if err != nil {
+			return nil, err
+		}
+		return pred, p.expectSymbol(")")
+	}
+	return p.condition(negate)
+}
+
+func (p *parser) condition(negate bool) (reader.Predicate, error) {
+	c, err := p.column()
+	if err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		if negate != not {
+			return reader.Not(reader.IsNull(c)), nil
+		}
+		return reader.IsNull(c), nil
+	}
+	not := p.acceptKeyword("NOT")
+	negate = negate != not
+	switch {
+	case p.acceptKeyword("IN"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		values := make([]string, 0)
+		for {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v.text)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return negated(c, reader.In(c, values...), negate), nil
+	case p.acceptKeyword("BETWEEN"):
+		min, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		max, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		return negated(c, reader.Between(c, min, max), negate), nil
+	case p.acceptKeyword("LIKE"):
+		t := p.peek()
+		if t.kind != tokString {
+			return nil, p.unexpected("a pattern")
+		}
+		p.i++
+		return negated(c, reader.Matches(c, likePattern(t.text)), negate), nil
+	case not:
+		return nil, p.unexpected("IN, BETWEEN or LIKE")
+	}
+
+	op := p.peek()
+	if op.kind != tokSymbol || !isComparison(op.text) {
+		return nil, p.unexpected("a comparison")
+	}
+	p.i++
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return negated(c, comparison(c, op.text, v), negate), nil
+}
+
+// negates the predicate of a condition on the column, a null or missing field never matches either way
+func negated(column string, pred reader.Predicate, negate bool) reader.Predicate {
+	if !negate {
+		return pred
+	}
+	return func(line reader.ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		return err == nil && !f.IsNull && !pred(line)
+	}
+}
+
+// parses a single quoted string or a number
+func (p *parser) value() (token, error) {
+	t := p.peek()
+	if t.kind != tokString && t.kind != tokNumber {
+		return t, p.unexpected("a value")
+	}
+	p.i++
+	return t, nil
+}
+
+func (p *parser) number() (float64, error) {
+	t := p.peek()
+	if t.kind != tokNumber {
+		return 0, p.unexpected("a number")
+	}
+	p.i++
+	return strconv.ParseFloat(t.text, 64)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+// converts a LIKE pattern to a regular expression, % matches any text and _ matches a single character
+func likePattern(like string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^(?s:")
+	for _, r := range like {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(")$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,77 @@
+package query_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/query"
+)
+
+func TestParse(t *testing.T) {
+	q, err := query.Parse(`SELECT Country, "Capital City" FROM ./data/countries.wsv WHERE Name = 'O''Brien' ORDER BY Country DESC, "Capital City" LIMIT 10`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(q.Columns) != 2 || q.Columns[1] != "Capital City" {
+		t.Errorf("expected 2 columns but got %q", q.Columns)
+	}
+	if q.From != "./data/countries.wsv" {
+		t.Errorf("expected the file ./data/countries.wsv but got %q", q.From)
+	}
+	if len(q.OrderBy) != 2 || q.OrderBy[0].Column != "Country" || !q.OrderBy[0].Desc || q.OrderBy[1].Desc {
+		t.Errorf("expected Country DESC, Capital City but got %+v", q.OrderBy)
+	}
+	if q.Limit != 10 || q.Where == nil {
+		t.Errorf("expected a condition and a limit of 10 but got %d", q.Limit)
+	}
+
+	q, err = query.Parse("select * from data.wsv")
+	if err != nil || q.Columns != nil || q.Where != nil || q.Limit != -1 {
+		t.Errorf("expected every column without a condition or limit but got %+v %v", q, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, q := range []string{
+		"",
+		"SELECT FROM data.wsv",
+		"SELECT Country data.wsv",
+		"SELECT Country FROM",
+		"SELECT Country FROM data.wsv WHERE",
+		"SELECT Country FROM data.wsv WHERE Country",
+		"SELECT Country FROM data.wsv WHERE Country = Capital",
+		"SELECT Country FROM data.wsv WHERE Country = 'Japan",
+		"SELECT Country FROM data.wsv WHERE (Country = 'Japan'",
+		"SELECT Country FROM data.wsv WHERE Population BETWEEN 1 AND 'x'",
+		"SELECT Country FROM data.wsv WHERE Country NOT = 'Japan'",
+		"SELECT Country FROM data.wsv ORDER Country",
+		"SELECT Country FROM data.wsv LIMIT -1",
+		"SELECT Country FROM data.wsv LIMIT 10 10",
+		"SELECT Country FROM data.wsv WHERE Country = 'Japan' ; DROP",
+	} {
+		_, err := query.Parse(q)
+		if !errors.Is(err, query.ErrSyntax) {
+			t.Errorf("expected %q to be a syntax error but got %v", q, err)
+		}
+	}
+}
+
+func TestParseUnexpectedEnd(t *testing.T) {
+	for _, q := range []string{
+		"SELECT a FROM",
+		"SELECT a FROM data.wsv LIMIT",
+		"SELECT a FROM data.wsv WHERE a",
+		"SELECT a FROM data.wsv WHERE a =",
+		"SELECT a FROM data.wsv WHERE a LIKE",
+		"SELECT a FROM data.wsv WHERE a IN (",
+		"SELECT a FROM data.wsv WHERE a BETWEEN",
+		"SELECT a FROM data.wsv WHERE a BETWEEN 1 AND",
+	} {
+		_, err := query.Parse(q)
+		if !errors.Is(err, query.ErrSyntax) || !strings.Contains(err.Error(), "unexpected end of query") {
+			t.Errorf("expected %q to be an unexpected end of query but got %v", q, err)
+		}
+	}
+}
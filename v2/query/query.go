@@ -0,0 +1,189 @@
+// Query WSV data with a subset of SQL.
+//
+//	q, err := query.Parse("SELECT Country, Capital FROM data.wsv WHERE Population > 1000000 ORDER BY Country DESC LIMIT 10")
+//	doc, err := q.Run(wsv.NewReader(file))
+package query
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+var ErrSyntax = errors.New("syntax error")
+
+func syntaxError(pos int, msg string) error {
+	return fmt.Errorf("%w at position %d: %s", ErrSyntax, pos+1, msg)
+}
+
+// A parsed query, see Parse
+type Query struct {
+	// the columns selected in order, nil selects every column
+	Columns []string
+	// the file named after FROM, running the query reads the reader it is given
+	From string
+	// the condition lines must match, nil matches every line
+	Where reader.Predicate
+	// the columns to order by, the first column is the primary order
+	OrderBy []Order
+	// the maximum number of lines, -1 for no limit
+	Limit int
+	// every column referenced by the query
+	referenced []string
+}
+
+// A column of ORDER BY, values are compared with document.Auto and nulls are the smallest value
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Run reads the lines of the reader matching the query and returns them as a document with the selected columns.
+//
+// Only the columns referenced by the query are read, see reader.Select. A column that is not in the data
+// results in an error wrapping reader.ErrFieldNotFound.
+func (q *Query) Run(r *reader.Reader) (*document.Document, error) {
+	if q.Columns != nil {
+		if err := r.Select(q.referenced...); err != nil {
+			return nil, err
+		}
+	}
+	if q.Where != nil {
+		r.Where(q.Where)
+	}
+
+	rows := make([]reader.ReaderLine, 0)
+	for line, err := range r.All() {
+		if err != nil {
+			return nil, err
+		}
+		if line.IsHeaderLine() && q.Columns == nil {
+			// every column is read, check the columns of the condition and order exist
+			if err := q.checkColumns(r.Headers()); err != nil {
+				return nil, err
+			}
+		}
+		if line.IsHeaderLine() || line.FieldCount() == 0 {
+			continue
+		}
+		rows = append(rows, line)
+		// without an order the lines after the limit are not read
+		if len(q.OrderBy) == 0 && q.Limit >= 0 && len(rows) >= q.Limit {
+			break
+		}
+	}
+
+	columns := q.Columns
+	if columns == nil {
+		columns = r.Headers()
+	}
+	// the order columns that are not selected are kept until the lines are sorted
+	all := slices.Clone(columns)
+	for _, o := range q.OrderBy {
+		if !slices.Contains(all, o.Column) {
+			all = append(all, o.Column)
+		}
+	}
+
+	doc := document.NewDocument()
+	if _, err := doc.AppendLine(document.Fields(all...)...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		line, err := doc.AddLine()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range all {
+			f, err := row.FieldByName(c)
+			if err != nil || f.IsNull {
+				err = line.AppendNull()
+			} else {
+				err = line.Append(f.Value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(q.OrderBy) > 0 {
+		// SortBy makes the last option the primary order
+		opts := make([]document.SortOption, len(q.OrderBy))
+		for i, o := range q.OrderBy {
+			opts[len(opts)-1-i] = document.SortOption{FieldName: o.Column, Desc: o.Desc}
+		}
+		if err := doc.SortBy(opts...); err != nil {
+			return nil, err
+		}
+	}
+	if q.Limit >= 0 && doc.LineCount() > q.Limit+1 {
+		if err := doc.Truncate(q.Limit + 1); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range all[len(columns):] {
+		if err := doc.DeleteColumn(c); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func (q *Query) checkColumns(headers []string) error {
+	for _, c := range q.referenced {
+		if !slices.Contains(headers, c) {
+			return fmt.Errorf("%w: column %q", reader.ErrFieldNotFound, c)
+		}
+	}
+	return nil
+}
+
+// returns a predicate comparing the column to the value, numerically if the value is a number
+func comparison(column string, op string, v token) reader.Predicate {
+	var num float64
+	if v.kind == tokNumber {
+		num, _ = strconv.ParseFloat(v.text, 64)
+	}
+	return func(line reader.ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		if err != nil || f.IsNull {
+			return false
+		}
+		c, ok := compareValue(f, v, num)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "=":
+			return c == 0
+		case "!=", "<>":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	}
+}
+
+// compares the field to the value, false is returned if the value is a number and the field is not
+func compareValue(f *record.RecordField, v token, num float64) (int, bool) {
+	if v.kind != tokNumber {
+		return strings.Compare(f.Value, v.text), true
+	}
+	n, err := f.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return cmp.Compare(n, num), true
+}
@@ -0,0 +1,131 @@
+package query_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/query"
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+const countries = `Country Capital Population Continent
+Japan Tokyo 125700000 Asia
+India "New Delhi" 1428600000 Asia
+Iceland Reykjavik 372000 Europe
+Germany Berlin 84400000 Europe
+Monaco Monaco 36000 Europe
+Atlantis - - -
+`
+
+func run(t *testing.T, q string) string {
+	t.Helper()
+	parsed, err := query.Parse(q)
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	doc, err := parsed.Run(reader.NewReader(strings.NewReader(countries)))
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	out, err := doc.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	return string(out)
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		q   string
+		exp string
+	}{
+		{
+			"SELECT Country, Capital FROM data.wsv WHERE Population > 1000000 ORDER BY Country DESC LIMIT 2",
+			"Country  Capital\nJapan    Tokyo\nIndia    \"New Delhi\"\n",
+		},
+		{
+			"select * from data.wsv where Continent = 'Europe' and not Country like 'M%' order by Population",
+			"Country  Capital    Population  Continent\nIceland  Reykjavik  372000      Europe\nGermany  Berlin     84400000    Europe\n",
+		},
+		{
+			"SELECT Capital FROM data.wsv ORDER BY Population DESC",
+			"Capital\n\"New Delhi\"\nTokyo\nBerlin\nReykjavik\nMonaco\n-\n",
+		},
+		{
+			"SELECT Country FROM data.wsv WHERE Capital IS NULL OR Country IN ('Japan', 'Peru')",
+			"Country\nJapan\nAtlantis\n",
+		},
+		{
+			"SELECT Country FROM data.wsv WHERE Population BETWEEN 100000 AND 100000000 AND (Continent <> 'Asia' OR Capital = 'Tokyo') LIMIT 1",
+			"Country\nIceland\n",
+		},
+		{
+			`SELECT "Country" FROM "my data.wsv" WHERE Continent = 'Europe' AND Population < 1e6 ORDER BY Continent, Country`,
+			"Country\nIceland\nMonaco\n",
+		},
+	}
+	for _, tt := range tests {
+		if out := run(t, tt.q); out != tt.exp {
+			t.Errorf("%s\nexpected\n%s\nbut got\n%s", tt.q, tt.exp, out)
+		}
+	}
+}
+
+func TestRunUnknownColumn(t *testing.T) {
+	for _, q := range []string{"SELECT Country FROM data.wsv WHERE Area > 5", "SELECT * FROM data.wsv ORDER BY Area"} {
+		parsed, err := query.Parse(q)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		_, err = parsed.Run(reader.NewReader(strings.NewReader(countries)))
+		if !errors.Is(err, reader.ErrFieldNotFound) {
+			t.Errorf("%s: expected an unknown column but got %v", q, err)
+		}
+	}
+}
+
+func TestRunOrderMixedValues(t *testing.T) {
+	parsed, err := query.Parse("SELECT Name FROM data.wsv ORDER BY Code, Name DESC")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data := "Name Code\na 1a\nb 9\nc -\nd 10\ne 9\n"
+	doc, err := parsed.Run(reader.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, _ := doc.WriteAll()
+	// numbers sort by value before text like document.Auto, ties are broken by the next column
+	if exp := "Name\nc\ne\nb\nd\na\n"; string(out) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, out)
+	}
+	if line, _ := doc.Line(2); line.FieldCount() != 1 {
+		t.Errorf("expected the order column Code not to be selected")
+	}
+}
+
+func TestRunNegatedNulls(t *testing.T) {
+	tests := []struct {
+		q   string
+		exp string
+	}{
+		{"SELECT Country FROM data.wsv WHERE Capital NOT IN ('Tokyo', 'Berlin')", "Country\nIndia\nIceland\nMonaco\n"},
+		{"SELECT Country FROM data.wsv WHERE Population NOT BETWEEN 100000 AND 100000000", "Country\nJapan\nIndia\nMonaco\n"},
+		{"SELECT Country FROM data.wsv WHERE Capital NOT LIKE '%o%'", "Country\nIndia\nIceland\nGermany\n"},
+		{"SELECT Country FROM data.wsv WHERE NOT Population > 1000000", "Country\nIceland\nMonaco\n"},
+		{"SELECT Country FROM data.wsv WHERE NOT (Capital = 'Tokyo' OR Population < 1000000)", "Country\nIndia\nGermany\n"},
+		{"SELECT Country FROM data.wsv WHERE NOT Capital IS NULL AND NOT NOT Continent = 'Asia'", "Country\nJapan\nIndia\n"},
+	}
+	for _, tt := range tests {
+		if out := run(t, tt.q); out != tt.exp {
+			t.Errorf("%s\nexpected\n%s\nbut got\n%s", tt.q, tt.exp, out)
+		}
+	}
+}
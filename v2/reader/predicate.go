@@ -0,0 +1,86 @@
+package reader
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// evaluates a line and should return true if the line is to be read, see Reader.Where
+type Predicate = func(line ReaderLine) bool
+
+// Matches lines where the column has the value, a null never matches
+func Equals(column string, value string) Predicate {
+	return func(line ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		return err == nil && !f.IsNull && f.Value == value
+	}
+}
+
+// Matches lines where the column has one of the values, a null never matches
+func In(column string, values ...string) Predicate {
+	return func(line ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		return err == nil && !f.IsNull && slices.Contains(values, f.Value)
+	}
+}
+
+// Matches lines where the value of the column matches the regular expression, a null never matches
+func Matches(column string, re *regexp.Regexp) Predicate {
+	return func(line ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		return err == nil && !f.IsNull && re.MatchString(f.Value)
+	}
+}
+
+// Matches lines where the value of the column is a number between min and max inclusive,
+// a null or a value that is not a number never matches
+func Between(column string, min float64, max float64) Predicate {
+	return func(line ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		if err != nil || f.IsNull {
+			return false
+		}
+		v, err := strconv.ParseFloat(f.Value, 64)
+		return err == nil && v >= min && v <= max
+	}
+}
+
+// Matches lines where the column is null
+func IsNull(column string) Predicate {
+	return func(line ReaderLine) bool {
+		f, err := line.FieldByName(column)
+		return err == nil && f.IsNull
+	}
+}
+
+// Matches lines the predicate does not match
+func Not(pred Predicate) Predicate {
+	return func(line ReaderLine) bool {
+		return !pred(line)
+	}
+}
+
+// Matches lines every predicate matches
+func And(preds ...Predicate) Predicate {
+	return func(line ReaderLine) bool {
+		for _, pred := range preds {
+			if !pred(line) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Matches lines any of the predicates matches
+func Or(preds ...Predicate) Predicate {
+	return func(line ReaderLine) bool {
+		for _, pred := range preds {
+			if pred(line) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package reader_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+const people = `Name Age Color
+Scott 33 red
+Jane 21 -
+John "-" green
+# a comment
+
+Zak 100 "red"
+`
+
+func readNames(t *testing.T, preds ...reader.Predicate) []string {
+	r := reader.NewReader(strings.NewReader(people))
+	for _, pred := range preds {
+		r.Where(pred)
+	}
+	lines, err := r.ReadAll()
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	if len(lines) == 0 || !lines[0].IsHeaderLine() {
+		t.Error("expected the header line to be read")
+		return nil
+	}
+	names := make([]string, 0)
+	for _, line := range lines[1:] {
+		if line.FieldCount() == 0 {
+			continue
+		}
+		f, _ := line.Field(0)
+		names = append(names, f.Value)
+	}
+	return names
+}
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		name  string
+		preds []reader.Predicate
+		exp   string
+	}{
+		{"none", nil, "Scott Jane John Zak"},
+		{"equals", []reader.Predicate{reader.Equals("Color", "red")}, "Scott Zak"},
+		{"literal dash", []reader.Predicate{reader.Equals("Age", "-")}, "John"},
+		{"in", []reader.Predicate{reader.In("Name", "Jane", "Zak", "Bob")}, "Jane Zak"},
+		{"matches", []reader.Predicate{reader.Matches("Name", regexp.MustCompile(`^J`))}, "Jane John"},
+		{"between", []reader.Predicate{reader.Between("Age", 21, 33)}, "Scott Jane"},
+		{"is null", []reader.Predicate{reader.IsNull("Color")}, "Jane"},
+		{"not", []reader.Predicate{reader.Not(reader.IsNull("Color"))}, "Scott John Zak"},
+		{"or", []reader.Predicate{reader.Or(reader.Equals("Name", "Scott"), reader.IsNull("Color"))}, "Scott Jane"},
+		{"and", []reader.Predicate{reader.And(reader.Equals("Color", "red"), reader.Between("Age", 50, 200))}, "Zak"},
+		{"successive calls", []reader.Predicate{reader.Equals("Color", "red"), reader.Equals("Name", "Scott")}, "Scott"},
+		{"unknown column", []reader.Predicate{reader.Equals("City", "Boston")}, ""},
+	}
+	for _, tt := range tests {
+		names := strings.Join(readNames(t, tt.preds...), " ")
+		if names != tt.exp {
+			t.Errorf("%s: expected %q but got %q", tt.name, tt.exp, names)
+		}
+	}
+}
+
+func TestWhereToDocument(t *testing.T) {
+	r := reader.NewReader(strings.NewReader(people))
+	r.Where(reader.Equals("Color", "red"))
+	doc, err := r.ToDocument()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if doc.LineCount() != 3 {
+		t.Errorf("expected the header and 2 lines but got %d", doc.LineCount())
+	}
+	line, err := doc.Line(3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if f, _ := line.Field(0); f.Value != "Zak" {
+		t.Errorf("expected Zak but got %q", f.Value)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 	"strings"
 
@@ -14,6 +15,8 @@ import (
 
 	doc "github.com/internetcalifornia/wsv/v2/document"
 	"github.com/internetcalifornia/wsv/v2/record"
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+	"github.com/internetcalifornia/wsv/v2/schema"
 	"github.com/internetcalifornia/wsv/v2/utils"
 )
 
@@ -35,6 +38,10 @@ type ParseError struct {
 	NeighborBytes []byte
 }
 
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 func (e *ParseError) Error() string {
 	if e.Err == ErrFieldCount {
 		return fmt.Sprintf("record on line %d: %v", e.Line, e.Err)
@@ -55,15 +62,116 @@ type Reader struct {
 	IncludesHeader      bool
 	IsTabular           bool
 	r                   *bufio.Reader
+	text                *reliabletxt.Reader
 	NullTrailingColumns bool
-	ended               bool
-	firstDataRow        int
+	// Keep every line read so it can be returned by Lines(), by default the reader only keeps the headers
+	RetainLines bool
+	// Record parse errors instead of returning them and keep the part of the line that could be recovered,
+	// the recorded errors are returned by Errors() and Err()
+	Lenient bool
+	// Validate every line against the schema declared in a `#@schema` comment before the header, on by default.
+	// Violations are returned like parse errors, or recorded in Lenient mode
+	EnforceSchema bool
+	// Keep the whitespace and quoting of every line, ToDocument sets it as the format of the document lines so an
+	// edited document is written back with the smallest textual change, see document.LineFormat
+	PreserveFormatting bool
+	schema             *schema.Schema
+	validator          *schema.Validator
+	schemaLine         int
+	// the columns set by Select and whether each column index is kept, resolved once the headers are read
+	selected     []string
+	keep         []bool
+	where        []Predicate
+	errs         []error
+	ended        bool
+	firstDataRow int
 }
 
 func (r *Reader) Headers() []string {
 	return r.headers
 }
 
+// Select limits the fields of the lines read to the named columns, other fields are skipped without allocating their values.
+// The fields keep the order of the columns in the data and FieldIndex is the position of the field in the projected line.
+//
+// The columns are resolved with IndexedAt once the header line is read, a column that is not found
+// results in an error wrapping ErrFieldNotFound, returned by Select or by the Read of the header line.
+// Calling Select without columns reads every field again.
+func (r *Reader) Select(columns ...string) error {
+	if len(columns) == 0 {
+		r.selected = nil
+		r.keep = nil
+		return nil
+	}
+	if !r.IncludesHeader {
+		return ErrNoHeaders
+	}
+	r.selected = columns
+	r.keep = nil
+	if r.firstDataRow == 0 {
+		return nil
+	}
+	return r.resolveSelect()
+}
+
+func (r *Reader) resolveSelect() error {
+	keep := make([]bool, len(r.headers))
+	for _, c := range r.selected {
+		idxs := r.IndexedAt(c)
+		if len(idxs) == 0 {
+			return fmt.Errorf("%w: column %q", ErrFieldNotFound, c)
+		}
+		for _, i := range idxs {
+			keep[i] = true
+		}
+	}
+	r.keep = keep
+	return nil
+}
+
+// whether the field at index i is kept by the projection
+func (r *Reader) selects(i int) bool {
+	return r.keep == nil || i < len(r.keep) && r.keep[i]
+}
+
+// Returns the schema declared in a `#@schema` comment before the header line, or nil if the data does not declare one
+func (r *Reader) Schema() *schema.Schema {
+	return r.schema
+}
+
+// Returns the parse errors recorded in Lenient mode
+func (r *Reader) Errors() []error {
+	return r.errs
+}
+
+// Returns the number of parse errors recorded in Lenient mode
+func (r *Reader) ErrorCount() int {
+	return len(r.errs)
+}
+
+// Returns the parse errors recorded in Lenient mode joined with errors.Join, or nil if there are none
+func (r *Reader) Err() error {
+	return errors.Join(r.errs...)
+}
+
+// in lenient mode records the error and returns nil so the caller continues, otherwise the error is returned
+func (r *Reader) fail(err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.Line == 0 {
+		parseErr.Line = r.numLine
+	}
+	if !r.Lenient {
+		return err
+	}
+	r.errs = append(r.errs, err)
+	return nil
+}
+
+// Returns the lines read so far, lines are only kept when RetainLines is set before reading
+func (r *Reader) Lines() []ReaderLine {
+	return r.lines
+}
+
 func columnName(headers []string, index int) string {
 	v, err := utils.GetIndexOfSlice(headers, index)
 	if err != nil {
@@ -72,17 +180,28 @@ func columnName(headers []string, index int) string {
 	return strings.Clone(*v)
 }
 
+// Creates a reader for WSV data in any ReliableTXT encoding, the encoding is detected from the byte order mark
+// which is removed before parsing. Data without a byte order mark is read as UTF-8.
 func NewReader(r io.Reader) *Reader {
+	text := reliabletxt.NewReader(r)
 	return &Reader{
-		r:                   bufio.NewReader(r),
+		r:                   bufio.NewReader(text),
+		text:                text,
 		IsTabular:           true,
 		IncludesHeader:      true,
 		NullTrailingColumns: true,
+		RetainLines:         false,
+		EnforceSchema:       true,
 		lines:               make([]ReaderLine, 0),
 		ended:               false,
 	}
 }
 
+// Returns the encoding of the data, detected once the first line has been read
+func (r *Reader) Encoding() reliabletxt.Encoding {
+	return r.text.Encoding()
+}
+
 // Return the column name at the index i, will return "" if not found
 func (r *Reader) ColumnNameOf(i int) (*string, error) {
 	return utils.GetIndexOfSlice(r.headers, i)
@@ -112,11 +231,36 @@ func Parse(wsvFile string) ([]ReaderLine, error) {
 	return records, err
 }
 
+// All returns an iterator over the remaining lines of the reader, ending at io.EOF.
+//
+// A ParseError is yielded along with the partial line and iteration continues with the next line,
+// any other error ends the iteration after it is yielded.
+func (r *Reader) All() iter.Seq2[ReaderLine, error] {
+	return func(yield func(ReaderLine, error) bool) {
+		for {
+			line, err := r.Read()
+			if err == io.EOF || err == ErrReaderEnded {
+				return
+			}
+			if !yield(line, err) {
+				return
+			}
+			var parseErr *ParseError
+			if err != nil && !errors.As(err, &parseErr) {
+				return
+			}
+		}
+	}
+}
+
+// Reads all remaining lines.
+//
+// In Lenient mode every line is returned along with the joined parse errors recorded, see Err()
 func (r *Reader) ReadAll() (records []ReaderLine, err error) {
 	for {
 		record, err := r.Read()
 		if err == io.EOF {
-			return records, nil
+			return records, r.Err()
 		}
 		if err != nil {
 			return nil, err
@@ -132,10 +276,24 @@ type LineField struct {
 }
 
 func ParseLine(n int, line []byte) ([]LineField, error) {
-	var b1 *byte = nil
-	var b2 *byte = nil
-	var b3 *byte = nil
-	var b4 *byte = nil
+	return parseLine(n, line, nil)
+}
+
+// returns the value of the field at index i, the string is only allocated for fields kept by the projection
+func fieldValue(keep []bool, i int, data []byte) string {
+	if keep != nil && (i >= len(keep) || !keep[i]) {
+		return ""
+	}
+	return string(data)
+}
+
+// parses the line like ParseLine, when keep is not nil the values of fields not kept are left empty
+func parseLine(n int, line []byte, keep []bool) ([]LineField, error) {
+	// the current rune and the three runes preceding it
+	var b1 *rune = nil
+	var b2 *rune = nil
+	var b3 *rune = nil
+	var b4 *rune = nil
 
 	doubleQuoted := false
 
@@ -144,10 +302,15 @@ func ParseLine(n int, line []byte) ([]LineField, error) {
 	escapedDoubleQuote := 0
 	data := []byte{}
 	str := make([]LineField, 0)
+	size := 0
 	// trim the trailing white space from the line
 	// line = bytes.TrimRightFunc(line, isFieldDelimiter)
 lineLoop:
-	for i, b0 := range line {
+	for i := 0; i < len(line); i += size {
+		var b0 rune
+		b0, size = utf8.DecodeRune(line[i:])
+		// whether the current rune is the last one of the line
+		last := i+size == len(line)
 		if b4 != nil {
 			b4 = b3
 			b3 = b2
@@ -172,11 +335,11 @@ lineLoop:
 		if b1 == nil {
 			b1 = &b0
 		}
-		r := rune(b0)
+		r := b0
 
 		switch r {
 		case '\n':
-			if i < len(line)-1 {
+			if !last {
 				d := neighborBytes(i, line)
 				return str, &ParseError{Line: n, Column: i, Err: ErrLineFeedTerm, NeighborBytes: d}
 			}
@@ -197,53 +360,53 @@ lineLoop:
 			data = append(data, byte(r))
 			continue
 		case '"':
-			if bytesToString(b3, b2, b1) == `"/"` {
+			if runesToString(b3, b2, b1) == `"/"` {
 				data = append(bytes.TrimSuffix(data, []byte{'/'}), byte('\n'))
 				continue
 			}
 
-			if (b2 == nil || utils.IsFieldDelimiter(rune(*b2))) && !doubleQuoted {
+			if (b2 == nil || utils.IsFieldDelimiter(*b2)) && !doubleQuoted {
 				doubleQuoted = true
 				startDoubleQuote = i
 				continue
 			}
 
-			if (b3 == nil || utils.IsFieldDelimiter(rune(*b3))) && b2 != nil && rune(*b2) == '"' && (len(line)-1 == i || (len(line)-1 > i && utils.IsFieldDelimiter(nextRune(line[i+1:])))) {
+			if (b3 == nil || utils.IsFieldDelimiter(*b3)) && b2 != nil && *b2 == '"' && (last || utils.IsFieldDelimiter(nextRune(line[i+1:]))) {
 				data = []byte{}
 				str = append(str, LineField{IsComment: false, Value: string(data), IsNull: isNull})
 				doubleQuoted = false
 				continue
 			}
 
-			if b2 != nil && rune(*b2) == '"' && (b3 == nil || rune(*b3) != '"') && !(len(line)-1 > i+1 && utils.IsFieldDelimiter(nextRune(line[i+1:])) && b3 != nil && rune(*b3) == '/') && !(len(line)-1 > i+2 && nextRune(line[i+1:]) == '/' && nextRune(line[i+2:]) == '"') {
+			if b2 != nil && *b2 == '"' && (b3 == nil || *b3 != '"') && !(len(line)-1 > i+1 && utils.IsFieldDelimiter(nextRune(line[i+1:])) && b3 != nil && *b3 == '/') && !(len(line)-1 > i+2 && nextRune(line[i+1:]) == '/' && nextRune(line[i+2:]) == '"') {
 				data = append(data, byte('"'))
 				escapedDoubleQuote = i
 				continue
 			}
 
-			if doubleQuoted && (len(line)-1 == i || (len(line)-1 > i && utils.IsFieldDelimiter(nextRune(line[i+1:])))) && (b2 == nil || rune(*b2) != '"' || i > escapedDoubleQuote) {
+			if doubleQuoted && (last || utils.IsFieldDelimiter(nextRune(line[i+1:]))) && (b2 == nil || *b2 != '"' || i > escapedDoubleQuote) {
 				doubleQuoted = false
 
 			}
 
 		case '-':
-			if r == '-' && (b2 == nil || utils.IsFieldDelimiter(rune(*b2))) && !doubleQuoted {
+			if r == '-' && (b2 == nil || utils.IsFieldDelimiter(*b2)) && !doubleQuoted {
 				isNull = true
 			}
 			fallthrough
 		default:
 
-			if bytesToString(b3, b2, b1) == `"/"` {
+			if runesToString(b3, b2, b1) == `"/"` {
 				data = append(bytes.TrimSuffix(data, []byte{'/'}), byte('\n'))
 			}
-			if isNull && (len(line)-1 == i) {
+			if isNull && last {
 				str = append(str, LineField{IsComment: false, Value: "", IsNull: isNull})
 				break lineLoop
 			}
 			// currently flagged as null but has more characters left to parse and
-			if isNull && len(line)-1 > i && bytes.IndexFunc(line[i:], utils.IsFieldDelimiter) != 1 {
+			if isNull && !last && bytes.IndexFunc(line[i:], utils.IsFieldDelimiter) != size {
 				// the next immediate character is a white space
-				if b2 != nil && rune(*b2) == '-' && bytes.IndexFunc([]byte{*b1}, utils.IsFieldDelimiter) == 0 {
+				if b2 != nil && *b2 == '-' && utils.IsFieldDelimiter(*b1) {
 					data = []byte{}
 				} else {
 					// and is not surround by double quotes we have an invalid
@@ -261,7 +424,7 @@ lineLoop:
 					nb := neighborBytes(i, line)
 					return str, &ParseError{Line: n, Err: ErrBareQuote, Column: i, NeighborBytes: nb}
 				}
-				str = append(str, LineField{IsComment: false, Value: string(data), IsNull: isNull})
+				str = append(str, LineField{IsComment: false, Value: fieldValue(keep, len(str), data), IsNull: isNull})
 				isNull = false
 				data = []byte{}
 				continue
@@ -270,7 +433,8 @@ lineLoop:
 				// since we identified the field as null and
 				continue
 			}
-			data = append(data, byte(r))
+			// copy the bytes of the rune as is, so invalid UTF-8 sequences are kept intact
+			data = append(data, line[i:i+size]...)
 			continue
 		}
 	}
@@ -285,7 +449,7 @@ lineLoop:
 			nb := neighborBytes(startDoubleQuote, line)
 			return str, &ParseError{Line: n, Err: ErrBareQuote, Column: startDoubleQuote, NeighborBytes: nb}
 		}
-		str = append(str, LineField{IsComment: false, Value: string(data), IsNull: isNull})
+		str = append(str, LineField{IsComment: false, Value: fieldValue(keep, len(str), data), IsNull: isNull})
 
 	}
 	return str, nil
@@ -310,13 +474,13 @@ func neighborBytes(i int, line []byte) (neighbor []byte) {
 	return neighbor
 }
 
-func bytesToString(s ...*byte) string {
+func runesToString(s ...*rune) string {
 	str := ""
-	for _, b := range s {
-		if b == nil {
+	for _, r := range s {
+		if r == nil {
 			continue
 		}
-		str = str + string(*b)
+		str = str + string(*r)
 	}
 	return str
 }
@@ -334,7 +498,45 @@ func (r *Reader) CurrentRow() int {
 // The partial record contains all fields read before the error.
 // If there is no data left to be read, Read returns an empty RecordField slice, io.EOF.
 // Subsequent calls to Read after io.EOF returns an empty RecordFieldSlice, ErrReaderEnded
+//
+// Lines not matching the predicates set by Where are skipped.
 func (r *Reader) Read() (ReaderLine, error) {
+	for {
+		line, err := r.read()
+		if err != nil || r.matches(line) {
+			return line, err
+		}
+	}
+}
+
+// Where only yields the lines matching the predicate from Read, ReadAll, All and ToDocument.
+// Predicates set by successive calls must all match, the header line always matches and lines without
+// data fields never match. Where(nil) removes every predicate.
+func (r *Reader) Where(pred Predicate) {
+	if pred == nil {
+		r.where = nil
+		return
+	}
+	r.where = append(r.where, pred)
+}
+
+func (r *Reader) matches(line ReaderLine) bool {
+	if len(r.where) == 0 || line.IsHeaderLine() {
+		return true
+	}
+	if line.FieldCount() == 0 {
+		return false
+	}
+	for _, pred := range r.where {
+		if !pred(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// reads the next line regardless of the predicates
+func (r *Reader) read() (ReaderLine, error) {
 	var data []byte
 	var errRead error
 	line := readerLine{
@@ -349,12 +551,30 @@ func (r *Reader) Read() (ReaderLine, error) {
 		r.ended = true
 		return &line, io.EOF
 	}
+	if errRead != nil {
+		return &line, errRead
+	}
 	line.line = r.numLine
+	if r.PreserveFormatting {
+		line.format = lineFormat(data)
+	}
 
-	fields, errRead := ParseLine(r.numLine, data)
+	// the header line is parsed in full as every column name is needed to resolve the projection
+	var keep []bool
+	if r.firstDataRow != 0 {
+		keep = r.keep
+	}
+	fields, errRead := parseLine(r.numLine, data, keep)
 	if errRead != nil {
-		return &line, errRead
+		// in lenient mode continue with the fields parsed before the error
+		errRead = r.fail(errRead)
+		if errRead != nil {
+			return &line, errRead
+		}
 	}
+	fieldCountExceeded := false
+	// number of data fields parsed, including the fields left out by the projection
+	parsed := 0
 	if len(fields) > 0 && r.firstDataRow == 0 && !fields[0].IsComment {
 		r.firstDataRow = r.numLine
 		if r.IncludesHeader {
@@ -373,54 +593,152 @@ func (r *Reader) Read() (ReaderLine, error) {
 			d.RowIndex = r.numLine
 			line.fields = append(line.fields, d)
 			line.fieldCount++
+			parsed++
 			continue
 		}
 		if field.IsComment {
 			// comments must be the first and only value or the last value parsed, if preceding fields are not explicitly defined return an error
 			if i < len(r.headers) && i != 0 {
-				return &line, &ParseError{Line: r.numLine, Column: 0, Err: ErrCommentPlacement}
+				err := r.fail(&ParseError{Line: r.numLine, Column: 0, Err: ErrCommentPlacement})
+				if err != nil {
+					return &line, err
+				}
 			}
 			line.comment = field.Value
 			continue
 		}
-		line.fieldCount++
+		parsed++
 
-		if r.IsTabular && r.IncludesHeader && len(r.headers) < line.fieldCount {
-			return &line, &ParseError{Line: r.numLine, Column: 0, Err: ErrFieldCount}
+		if r.IsTabular && r.IncludesHeader && len(r.headers) < parsed {
+			if !r.Lenient {
+				return &line, &ParseError{Line: r.numLine, Column: 0, Err: ErrFieldCount}
+			}
+			// drop the fields without a header, recording the error once for the line
+			parsed--
+			if !fieldCountExceeded {
+				fieldCountExceeded = true
+				r.fail(&ParseError{Line: r.numLine, Column: 0, Err: ErrFieldCount})
+			}
+			continue
+		}
+		if !r.selects(i) {
+			continue
 		}
 		fieldName := columnName(r.headers, i)
-		d := record.RecordField{Value: field.Value, FieldName: fieldName, IsHeader: false, RowIndex: r.numLine, FieldIndex: i, IsNull: false}
+		d := record.RecordField{Value: field.Value, FieldName: fieldName, IsHeader: false, RowIndex: r.numLine, FieldIndex: len(line.fields), IsNull: false}
 		if field.IsNull {
 			d.IsNull = true
 			d.Value = ""
 		}
 		line.fields = append(line.fields, d)
+		line.fieldCount++
 	}
 
-	if len(line.fields) == 0 {
-		return &line, errRead
-	}
-
-	if len(line.fields) == 0 && len(line.comment) == 0 {
+	if len(line.fields) == 0 && parsed == 0 {
+		if r.firstDataRow == 0 && r.schema == nil {
+			errRead = r.readSchema(&line)
+		}
 		return &line, errRead
 	}
 
-	if r.numLine != 1 && r.NullTrailingColumns && len(line.fields) < len(r.headers) {
-		x := len(r.headers) - len(line.fields)
-		o := len(line.fields)
-		for i := range x {
-			h := o + i
+	if r.numLine != 1 && r.NullTrailingColumns && parsed < len(r.headers) {
+		for h := parsed; h < len(r.headers); h++ {
+			if !r.selects(h) {
+				continue
+			}
 			cname := columnName(r.headers, h)
-			rec := record.RecordField{IsNull: true, Value: "", FieldIndex: h, RowIndex: r.numLine, FieldName: cname, IsHeader: false}
+			rec := record.RecordField{IsNull: true, Value: "", FieldIndex: len(line.fields), RowIndex: r.numLine, FieldName: cname, IsHeader: false}
 			line.fields = append(line.fields, rec)
 			line.fieldCount++
 		}
 	}
-	r.lines = append(r.lines, &line)
+	if r.validator != nil && r.EnforceSchema {
+		for _, v := range r.validator.ValidateLine(&line) {
+			if err := r.fail(v); err != nil {
+				return &line, err
+			}
+		}
+	}
+	// the header line is validated before the projection, as the schema's columns are checked against every header
+	if line.isHeaderLine && r.selected != nil {
+		if err := r.resolveSelect(); err != nil {
+			return &line, err
+		}
+		line.project(r.keep)
+	}
+	if r.RetainLines {
+		r.lines = append(r.lines, &line)
+	}
 	return &line, errRead
 
 }
 
+// parses the schema declared by a comment line preceding the header
+func (r *Reader) readSchema(line *readerLine) error {
+	s, ok, err := schema.ParseComment(line.comment)
+	if !ok {
+		return nil
+	}
+	if err != nil {
+		return r.fail(&ParseError{Line: r.numLine, Column: 0, Err: err})
+	}
+	r.schema = s
+	r.validator = s.NewValidator()
+	r.schemaLine = r.numLine
+	return nil
+}
+
+// splits the line into the whitespace before each field and the text of the fields as written, up to the comment
+func lineFormat(line []byte) *doc.LineFormat {
+	f := &doc.LineFormat{}
+	i := 0
+	for {
+		start := i
+		for i < len(line) {
+			r, size := utf8.DecodeRune(line[i:])
+			if !utils.IsFieldDelimiter(r) {
+				break
+			}
+			i += size
+		}
+		f.Whitespace = append(f.Whitespace, string(line[start:i]))
+		if i >= len(line) || line[i] == '#' {
+			return f
+		}
+		start = i
+		quoted := false
+	field:
+		for i < len(line) {
+			if quoted {
+				if line[i] == '"' {
+					switch {
+					case i+1 < len(line) && line[i+1] == '"':
+						// escaped double quote
+						i += 2
+						continue
+					case i+2 < len(line) && line[i+1] == '/' && line[i+2] == '"':
+						// line feed
+						i += 3
+						continue
+					}
+					quoted = false
+				}
+				i++
+				continue
+			}
+			r, size := utf8.DecodeRune(line[i:])
+			switch {
+			case r == '#' || utils.IsFieldDelimiter(r):
+				break field
+			case r == '"':
+				quoted = true
+			}
+			i += size
+		}
+		f.Fields = append(f.Fields, string(line[start:i]))
+	}
+}
+
 func nextRune(b []byte) rune {
 	r, _ := utf8.DecodeRune(b)
 	return r
@@ -455,27 +773,87 @@ func (r *Reader) readLine() ([]byte, error) {
 	return line, err
 }
 
+// Controls how ToDocumentWith converts the lines read, the zero value keeps every null, comment and empty line
+type ToDocumentOptions struct {
+	// write nulls as empty strings
+	NullsAsEmpty bool
+	// leave out the comments of lines and the lines that only hold a comment
+	OmitComments bool
+	// leave out the lines without values or a comment
+	OmitEmptyLines bool
+}
+
+// Reads the remaining lines into a document, keeping nulls, comments and empty lines.
+// The schema declared by the data is set on the document, with PreserveFormatting the schema comment is also kept as
+// the line it was read from
 func (r *Reader) ToDocument() (*doc.Document, error) {
-	doc := doc.NewDocument()
-	var err error
-	var rl ReaderLine
+	return r.ToDocumentWith(ToDocumentOptions{})
+}
+
+// Reads the remaining lines into a document converted by the options. The document is tabular and has a header line
+// when the reader is configured so with IsTabular and IncludesHeader.
+//
+// A line that cannot be added to the document ends the conversion with an error naming the line, the document holds
+// the lines converted before it
+func (r *Reader) ToDocumentWith(opts ToDocumentOptions) (*doc.Document, error) {
+	d := doc.NewDocument()
+	d.Tabular = r.IsTabular
+	if err := d.SetHasHeaders(r.IncludesHeader); err != nil {
+		return d, err
+	}
+	// the number of fields of the first line with values, every line with values of a tabular document has as many
+	columns := 0
 	for {
-		rl, err = r.Read()
+		rl, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return doc, err
+			return d, err
+		}
+		if rl.LineNumber() == r.schemaLine && !r.PreserveFormatting {
+			continue
+		}
+		if rl.FieldCount() == 0 && rl.Comment() != "" && opts.OmitComments {
+			continue
+		}
+		if rl.FieldCount() == 0 && rl.Comment() == "" && opts.OmitEmptyLines {
+			continue
+		}
+		comment := rl.Comment()
+		if opts.OmitComments {
+			comment = ""
 		}
-		line, err := doc.AddLine()
+		line, err := d.AddLine()
 		if err != nil {
-			return nil, err
+			return d, err
 		}
+		// the format is only kept for lines written with the values they were read with
+		keepFormat := comment == rl.Comment()
 		for i := range rl.FieldCount() {
 			field, _ := rl.Field(i)
-			line.Append(field.Value)
+			if field.IsNull && !opts.NullsAsEmpty {
+				err = line.AppendNull()
+			} else {
+				keepFormat = keepFormat && !field.IsNull
+				err = line.Append(field.Value)
+			}
+			if err != nil {
+				return d, fmt.Errorf("line %d: %w", rl.LineNumber(), err)
+			}
+		}
+		if columns == 0 {
+			columns = line.FieldCount()
+		} else if d.Tabular && line.FieldCount() != 0 && line.FieldCount() != columns {
+			return d, fmt.Errorf("line %d: %w", rl.LineNumber(), doc.ErrFieldCount)
+		}
+		line.UpdateComment(comment)
+		if l, ok := rl.(*readerLine); ok && l.format != nil && keepFormat {
+			line.SetFormat(l.format)
 		}
 	}
-
-	return doc, nil
+	if r.schema != nil {
+		d.SetSchema(r.schema)
+	}
+	return d, nil
 }
@@ -2,7 +2,9 @@ package reader
 
 import (
 	"errors"
+	"iter"
 
+	doc "github.com/internetcalifornia/wsv/v2/document"
 	"github.com/internetcalifornia/wsv/v2/record"
 )
 
@@ -13,6 +15,8 @@ var (
 
 type ReaderLine interface {
 	Field(fi int) (*record.RecordField, error)
+	// Get the first field in the column, or ErrFieldNotFound
+	FieldByName(name string) (*record.RecordField, error)
 	// Get the value of comment for the line
 	Comment() string
 	// Get the line number
@@ -21,6 +25,8 @@ type ReaderLine interface {
 	FieldCount() int
 	// Get the next field value, or error if at the end of the line for data
 	NextField() (*record.RecordField, error)
+	// Iterate over the index and value of every field, independent of the NextField cursor
+	Fields() iter.Seq2[int, *record.RecordField]
 	// Returns true if the line is a slice of headers
 	IsHeaderLine() bool
 }
@@ -34,6 +40,34 @@ type readerLine struct {
 	fieldCount   int
 	currentField int
 	isHeaderLine bool
+	// the whitespace and quoting of the line as read, only kept with PreserveFormatting
+	format *doc.LineFormat
+}
+
+// Creates a line from fields that were not parsed from text, such as a line of a binary encoded document.
+// Lines are 1-indexed
+func NewLine(n int, fields []record.RecordField, comment string, isHeader bool) ReaderLine {
+	return &readerLine{
+		fields:       fields,
+		comment:      comment,
+		line:         n,
+		fieldCount:   len(fields),
+		isHeaderLine: isHeader,
+	}
+}
+
+// removes the fields not kept, re-indexing the remaining fields
+func (line *readerLine) project(keep []bool) {
+	fields := make([]record.RecordField, 0, len(line.fields))
+	for i, f := range line.fields {
+		if i >= len(keep) || !keep[i] {
+			continue
+		}
+		f.FieldIndex = len(fields)
+		fields = append(fields, f)
+	}
+	line.fields = fields
+	line.fieldCount = len(fields)
 }
 
 func (line *readerLine) NextField() (*record.RecordField, error) {
@@ -45,6 +79,16 @@ func (line *readerLine) NextField() (*record.RecordField, error) {
 	return &line.fields[fieldInd], nil
 }
 
+func (line *readerLine) Fields() iter.Seq2[int, *record.RecordField] {
+	return func(yield func(int, *record.RecordField) bool) {
+		for i := range line.fields {
+			if !yield(i, &line.fields[i]) {
+				return
+			}
+		}
+	}
+}
+
 // Returns the number of data fields, non-comment fields
 func (line *readerLine) FieldCount() int {
 	return line.fieldCount
@@ -61,6 +105,15 @@ func (line *readerLine) Field(fieldIndex int) (*record.RecordField, error) {
 	return &line.fields[fieldIndex], nil
 }
 
+func (line *readerLine) FieldByName(name string) (*record.RecordField, error) {
+	for i := range line.fields {
+		if line.fields[i].FieldName == name {
+			return &line.fields[i], nil
+		}
+	}
+	return nil, ErrFieldNotFound
+}
+
 func (line *readerLine) Comment() string {
 	return line.comment
 }
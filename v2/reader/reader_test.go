@@ -1,6 +1,8 @@
 package reader_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +12,8 @@ import (
 	"github.com/internetcalifornia/wsv/v2/document"
 	doc "github.com/internetcalifornia/wsv/v2/document"
 	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+	"github.com/internetcalifornia/wsv/v2/schema"
 	"github.com/internetcalifornia/wsv/v2/utils"
 )
 
@@ -2757,3 +2761,343 @@ func TestReadingAndSortingNumberFields(t *testing.T) {
 		return
 	}
 }
+
+func TestReaderRetainLines(t *testing.T) {
+	data := "Name Age\nScott 33\nJane 21\n"
+	r := reader.NewReader(strings.NewReader(data))
+	_, err := r.ReadAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(r.Lines()) != 0 {
+		t.Error("expected the reader not to retain lines by default but got", len(r.Lines()))
+	}
+	if len(r.Headers()) != 2 {
+		t.Error("expected the headers to be kept but got", r.Headers())
+	}
+
+	r = reader.NewReader(strings.NewReader(data))
+	r.RetainLines = true
+	_, err = r.ReadAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(r.Lines()) != 3 {
+		t.Error("expected 3 retained lines but got", len(r.Lines()))
+	}
+}
+
+func TestReaderAllAndFields(t *testing.T) {
+	data := "Name Age\nScott 33\n\"bad\"x 1\nJane -\n"
+	r := reader.NewReader(strings.NewReader(data))
+	names := []string{}
+	errs := 0
+	for line, err := range r.All() {
+		if err != nil {
+			errs++
+			continue
+		}
+		if line.IsHeaderLine() {
+			continue
+		}
+		for i, field := range line.Fields() {
+			if i == 0 {
+				names = append(names, field.Value)
+			}
+		}
+		// a second pass over the same line yields the fields again
+		count := 0
+		for range line.Fields() {
+			count++
+		}
+		if count != 2 {
+			t.Error("expected the second pass to yield 2 fields but got", count)
+		}
+	}
+	if errs != 1 {
+		t.Error("expected 1 parse error but got", errs)
+	}
+	if strings.Join(names, ",") != "Scott,Jane" {
+		t.Error("expected the names Scott,Jane but got", names)
+	}
+}
+
+func TestReadWithByteOrderMark(t *testing.T) {
+	text := "Name Age\nScott 33\n"
+	for _, enc := range []reliabletxt.Encoding{reliabletxt.UTF8, reliabletxt.UTF16LE, reliabletxt.UTF32BE} {
+		b, err := reliabletxt.Encode([]byte(text), enc)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		r := reader.NewReader(bytes.NewReader(b))
+		lines, err := r.ReadAll()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if r.Encoding() != enc {
+			t.Errorf("expected the encoding %s but got %s", enc, r.Encoding())
+		}
+		if len(r.Headers()) != 2 || r.Headers()[0] != "Name" {
+			t.Errorf("expected the byte order mark to be removed from the headers %q", r.Headers())
+		}
+		if len(lines) != 2 {
+			t.Errorf("expected 2 lines but got %d", len(lines))
+		}
+	}
+}
+
+func TestReadLenient(t *testing.T) {
+	lines := []string{
+		`Name Age Color`,
+		`Scott 33 red`,
+		`Jane "21 blue`,
+		`John -old green`,
+		`Zak 100 pink extra values`,
+		`Patrick 55 #comment`,
+		`Mary 40 grey`,
+	}
+	r := reader.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	r.Lenient = true
+	records, err := r.ReadAll()
+	if err == nil {
+		t.Error("expected the recorded errors to be returned")
+		return
+	}
+	if len(records) != 7 {
+		t.Errorf("expected every line to be returned but got %d", len(records))
+	}
+	if r.ErrorCount() != 4 {
+		t.Errorf("expected 4 errors but got %d: %v", r.ErrorCount(), err)
+		return
+	}
+	if !errors.Is(err, reader.ErrBareQuote) || !errors.Is(err, reader.ErrInvalidNull) || !errors.Is(err, reader.ErrFieldCount) || !errors.Is(err, reader.ErrCommentPlacement) {
+		t.Errorf("expected the joined errors to include every kind but got %v", err)
+	}
+	for i, exp := range []int{3, 4, 5, 6} {
+		var parseErr *reader.ParseError
+		if !errors.As(r.Errors()[i], &parseErr) || parseErr.Line != exp {
+			t.Errorf("expected error %d to be on line %d but got %v", i, exp, r.Errors()[i])
+		}
+	}
+	if records[3].FieldCount() != 3 {
+		t.Errorf("expected the invalid null line to be recovered with 3 fields but got %d", records[3].FieldCount())
+	}
+	if f, _ := records[4].Field(2); records[4].FieldCount() != 3 || f.Value != "pink" {
+		t.Errorf("expected the extra values to be dropped but got %d fields", records[4].FieldCount())
+	}
+	if records[5].Comment() != "comment" {
+		t.Errorf("expected the misplaced comment to be kept but got %q", records[5].Comment())
+	}
+	if f, _ := records[6].Field(0); f.Value != "Mary" {
+		t.Errorf("expected reading to continue after errors but got %+v", f)
+	}
+}
+
+func TestReadSchema(t *testing.T) {
+	data := "#@schema Name:string Age:int?\nName Age\nScott 33\nJane -\nJohn old\n"
+	r := reader.NewReader(strings.NewReader(data))
+	_, err := r.ReadAll()
+	if !errors.Is(err, schema.ErrInvalidValue) {
+		t.Errorf("expected an invalid value but got %v", err)
+	}
+	if r.Schema() == nil || r.Schema().String() != "Name:string Age:int?" {
+		t.Errorf("expected the declared schema but got %v", r.Schema())
+	}
+
+	r = reader.NewReader(strings.NewReader(data))
+	r.Lenient = true
+	records, err := r.ReadAll()
+	var violation schema.Violation
+	if !errors.As(err, &violation) || violation.Line != 5 || violation.Column != "Age" {
+		t.Errorf("expected a violation on line 5 but got %v", err)
+	}
+	if len(records) != 5 {
+		t.Errorf("expected 5 lines but got %d", len(records))
+	}
+
+	r = reader.NewReader(strings.NewReader(data))
+	r.EnforceSchema = false
+	if _, err := r.ReadAll(); err != nil {
+		t.Errorf("expected the schema not to be enforced but got %v", err)
+	}
+
+	r = reader.NewReader(strings.NewReader("#@schema Name:string Age:int?\nName Age\nScott 33\n"))
+	d, err := r.ToDocument()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, err := d.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if exp := "#@schema Name:string Age:int?\nName   Age\nScott  33\n"; string(out) != exp {
+		t.Errorf("expected the schema to be written back\n%s\nbut got\n%s", exp, out)
+	}
+}
+
+func TestReadSelect(t *testing.T) {
+	data := "Name Age Color City\nScott 33 red Boston\nJane 21\n"
+	r := reader.NewReader(strings.NewReader(data))
+	err := r.Select("City", "Name")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lines, err := r.ReadAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := [][]string{{"Name", "City"}, {"Scott", "Boston"}, {"Jane", ""}}
+	for i, line := range lines {
+		if line.FieldCount() != len(exp[i]) {
+			t.Errorf("expected line %d to have %d fields but got %d", i+1, len(exp[i]), line.FieldCount())
+			continue
+		}
+		for fi, v := range exp[i] {
+			f, _ := line.Field(fi)
+			if f.Value != v || f.FieldIndex != fi {
+				t.Errorf("expected field %d of line %d to be %q but got %+v", fi, i+1, v, f)
+			}
+		}
+	}
+	if f, _ := lines[2].Field(1); !f.IsNull || f.FieldName != "City" {
+		t.Errorf("expected the missing city to be null but got %+v", f)
+	}
+	if len(r.Headers()) != 4 {
+		t.Errorf("expected every header to be kept but got %q", r.Headers())
+	}
+
+	r = reader.NewReader(strings.NewReader(data))
+	r.Select("Country")
+	_, err = r.Read()
+	if !errors.Is(err, reader.ErrFieldNotFound) {
+		t.Errorf("expected an unknown column error but got %v", err)
+	}
+	r = reader.NewReader(strings.NewReader(data))
+	r.Read()
+	if err := r.Select("Age", "Country"); !errors.Is(err, reader.ErrFieldNotFound) {
+		t.Errorf("expected an unknown column error but got %v", err)
+	}
+}
+
+func TestPreserveFormatting(t *testing.T) {
+	data := "#@schema  Name:string Age:int?\n" +
+		"# people\n" +
+		"Name       Age   \"Favorite Color\"  # header\n" +
+		"Scott      33    red\n" +
+		"  \n" +
+		"\"Jane\"     -     \"sky \"\"blue\"\"\"\n" +
+		"Bob\t5\t\"a\"/\"b\"   \n"
+	r := reader.NewReader(strings.NewReader(data))
+	r.PreserveFormatting = true
+	d, err := r.ToDocument()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, err := d.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(out) != data {
+		t.Errorf("expected the document to be written back unchanged\n%s\nbut got\n%s", data, out)
+	}
+	if d.Schema() == nil {
+		t.Error("expected the schema to be set on the document")
+	}
+
+	line, _ := d.Line(4)
+	line.UpdateField(1, "100")
+	line, _ = d.Line(6)
+	line.UpdateField(1, "21")
+	line.UpdateComment("edited")
+	d.ResetWrite()
+	out, err = d.WriteAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := strings.Replace(data, "Scott      33    red", "Scott      100   red", 1)
+	exp = strings.Replace(exp, "\"Jane\"     -     \"sky \"\"blue\"\"\"", "\"Jane\"     21    \"sky \"\"blue\"\"\" #edited", 1)
+	if string(out) != exp {
+		t.Errorf("expected only the edited fields to change\n%s\nbut got\n%s", exp, out)
+	}
+}
+
+func TestToDocumentWith(t *testing.T) {
+	data := "# people\nName Age\nScott - # unknown age\n\nJane 21\n"
+	r := reader.NewReader(strings.NewReader(data))
+	d, err := r.ToDocument()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	line, _ := d.Line(3)
+	if f, _ := line.Field(1); !f.IsNull || line.Comment() != " unknown age" {
+		t.Errorf("expected the null and the comment to be kept but got %+v %q", f, line.Comment())
+	}
+	out, _ := d.WriteAll()
+	if exp := "# people\nName   Age\nScott  -  # unknown age\n\nJane   21\n"; string(out) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, out)
+	}
+
+	r = reader.NewReader(strings.NewReader(data))
+	d, err = r.ToDocumentWith(reader.ToDocumentOptions{NullsAsEmpty: true, OmitComments: true, OmitEmptyLines: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, _ = d.WriteAll()
+	if exp := "Name   Age\nScott  \"\"\nJane   21\n"; string(out) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, out)
+	}
+
+	r = reader.NewReader(strings.NewReader("a b\nc d\n"))
+	r.IncludesHeader = false
+	d, err = r.ToDocument()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.HasHeaders() || len(d.Headers()) != 0 {
+		t.Errorf("expected a document without headers but got %q", d.Headers())
+	}
+	out, err = d.WriteAll()
+	if exp := "a  b\nc  d\n"; err != nil || string(out) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s %v", exp, out, err)
+	}
+
+	r = reader.NewReader(strings.NewReader("a b\nc d e\n"))
+	r.IncludesHeader = false
+	if _, err := r.ToDocument(); !errors.Is(err, document.ErrFieldCount) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a field count error on line 2 but got %v", err)
+	}
+
+	r = reader.NewReader(strings.NewReader("a b\nc d\ne\n"))
+	r.IncludesHeader = false
+	if _, err := r.ToDocument(); !errors.Is(err, document.ErrFieldCount) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected a field count error on the short line 3 but got %v", err)
+	}
+
+	r = reader.NewReader(strings.NewReader("a b\nc\n"))
+	r.NullTrailingColumns = false
+	if _, err := r.ToDocument(); !errors.Is(err, document.ErrFieldCount) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a field count error on the short line 2 but got %v", err)
+	}
+
+	r = reader.NewReader(strings.NewReader("a b\nc d e\n"))
+	r.IncludesHeader = false
+	r.IsTabular = false
+	d, err = r.ToDocument()
+	if err != nil || d.Tabular {
+		t.Errorf("expected a non-tabular document but got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package reader
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/internetcalifornia/wsv/v2/utils"
+)
+
+var (
+	ErrInvalidDecodeTarget = errors.New("decode target must be a non-nil pointer to a struct")
+	ErrInvalidUnmarshal    = errors.New("unmarshal target must be a non-nil pointer to a slice of structs")
+	ErrUnsupportedType     = errors.New("unsupported field type")
+	ErrNoHeaders           = errors.New("reader has no headers to map struct fields against")
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// A DecodeError is returned when a field value cannot be assigned to a struct field.
+// Line numbers are 1-indexed.
+type DecodeError struct {
+	Line   int    // Line where the error occurred
+	Column string // Name of the column being decoded
+	Field  string // Name of the struct field being assigned
+	Err    error  // The actual error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode error on line %d, column %q into field %s: %v", e.Line, e.Column, e.Field, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decode reads the next data line from the reader and stores its values in the struct pointed to by v.
+//
+// Struct fields are matched to columns of Headers() with the `wsv` struct tag, header, empty and comment-only
+// lines are skipped. A null value leaves pointer fields nil and other fields at their zero value.
+//
+// If there is no data left to be read, Decode returns io.EOF.
+func (r *Reader) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidDecodeTarget
+	}
+	for {
+		line, err := r.Read()
+		if err != nil {
+			return err
+		}
+		if line.IsHeaderLine() || line.FieldCount() == 0 {
+			continue
+		}
+		return r.decodeLine(line, rv.Elem())
+	}
+}
+
+// Unmarshal parses the WSV encoded data and appends one element for every data line to the slice pointed to by v.
+// The slice elements can be structs or pointers to structs, see Reader.Decode for how fields are mapped.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return ErrInvalidUnmarshal
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return ErrInvalidUnmarshal
+	}
+
+	r := NewReader(bytes.NewReader(data))
+	for {
+		elem := reflect.New(elemType)
+		err := r.Decode(elem.Interface())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+			continue
+		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+}
+
+func (r *Reader) decodeLine(line ReaderLine, rv reflect.Value) error {
+	if len(r.headers) == 0 {
+		return ErrNoHeaders
+	}
+	for _, tf := range utils.TaggedFields(rv.Type()) {
+		// fields are matched by name as a projection leaves out columns, see Select
+		field, err := line.FieldByName(tf.Column)
+		if err != nil {
+			continue
+		}
+		err = setValue(rv.Field(tf.Index), field.Value, field.IsNull, tf.Layout)
+		if err != nil {
+			return &DecodeError{Line: line.LineNumber(), Column: tf.Column, Field: tf.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, val string, isNull bool, layout string) error {
+	if v.Kind() == reflect.Pointer {
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), val, isNull, layout)
+	}
+	if isNull {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	// time.Time is a text unmarshaler as well, check it first so the layout option is honoured
+	if v.Type() == timeType {
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("%w %s", ErrUnsupportedType, v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package reader_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+type favoriteColor struct {
+	Name string
+}
+
+func (c *favoriteColor) UnmarshalText(b []byte) error {
+	c.Name = strings.ToUpper(string(b))
+	return nil
+}
+
+type person struct {
+	Given   string        `wsv:"Given Name"`
+	Age     int           `wsv:"Age"`
+	Height  *float64      `wsv:"Height"`
+	Member  bool          `wsv:"Member"`
+	Born    time.Time     `wsv:"Date of Birth,layout=2006-01-02"`
+	Color   favoriteColor `wsv:"Favorite Color"`
+	Ignored string        `wsv:"-"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	lines := []string{
+		`"Given Name"  Age  Height  Member  "Date of Birth"  "Favorite Color"`,
+		`Jean          33   1.75    true    "2023-01-01"     purple #first`,
+		``,
+		`#a comment`,
+		`Mary          21   -       false   "2021-02-02"     grey`,
+	}
+	var people []person
+	err := reader.Unmarshal([]byte(strings.Join(lines, "\n")), &people)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(people) != 2 {
+		t.Errorf("expected 2 people but got %d instead", len(people))
+		return
+	}
+	p := people[0]
+	if p.Given != "Jean" || p.Age != 33 || p.Height == nil || *p.Height != 1.75 || !p.Member || p.Color.Name != "PURPLE" {
+		t.Errorf("unexpected values decoded %+v", p)
+	}
+	if !p.Born.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected date of birth 2023-01-01 but got %s instead", p.Born)
+	}
+	p = people[1]
+	if p.Given != "Mary" || p.Age != 21 || p.Height != nil || p.Member {
+		t.Errorf("unexpected values decoded %+v", p)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	lines := []string{
+		`"Given Name"  Age`,
+		`Jean          thirty`,
+	}
+	r := reader.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	var p person
+	err := r.Decode(&p)
+	var decodeErr *reader.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("expected a decode error but got %v instead", err)
+		return
+	}
+	if decodeErr.Line != 2 || decodeErr.Column != "Age" || decodeErr.Field != "Age" {
+		t.Errorf("unexpected decode error %+v", decodeErr)
+	}
+	err = r.Decode(&p)
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v instead", err)
+	}
+	err = r.Decode(p)
+	if err != reader.ErrInvalidDecodeTarget {
+		t.Errorf("expected invalid decode target but got %v instead", err)
+	}
+}
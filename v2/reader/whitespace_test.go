@@ -0,0 +1,100 @@
+package reader_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/utils"
+)
+
+func TestWhitespaceCodePoints(t *testing.T) {
+	if len(utils.Whitespace) != 24 {
+		t.Errorf("expected 24 whitespace code points but got %d", len(utils.Whitespace))
+	}
+	for _, ws := range utils.Whitespace {
+		if !utils.IsFieldDelimiter(ws) {
+			t.Errorf("expected %U to be a field delimiter", ws)
+		}
+	}
+	// bytes of multi-byte characters must not be mistaken for U+0085 or U+00A0
+	fields, err := reader.ParseLine(1, []byte("Åland à_la_carte"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(fields) != 2 || fields[0].Value != "Åland" || fields[1].Value != "à_la_carte" {
+		t.Errorf("expected [Åland à_la_carte] but got %+v", fields)
+	}
+}
+
+func TestParseLineWithUnicodeWhitespace(t *testing.T) {
+	for _, ws := range utils.Whitespace {
+		t.Run(fmt.Sprintf("%U", ws), func(t *testing.T) {
+			w := string(ws)
+			line := "à" + w + "b" + w + w + `"c` + w + `d"` + w + "-" + w + "#note"
+			fields, err := reader.ParseLine(1, []byte(line))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(fields) != 5 {
+				t.Errorf("expected 5 fields but got %d %+v", len(fields), fields)
+				return
+			}
+			if fields[0].Value != "à" || fields[1].Value != "b" || fields[2].Value != "c"+w+"d" {
+				t.Errorf("unexpected values %+v", fields)
+			}
+			if !fields[3].IsNull {
+				t.Errorf("expected field 4 to be null but got %+v", fields[3])
+			}
+			if !fields[4].IsComment || fields[4].Value != "note" {
+				t.Errorf("expected the comment note but got %+v", fields[4])
+			}
+		})
+	}
+}
+
+func TestRoundTripUnicodePadding(t *testing.T) {
+	for _, ws := range utils.Whitespace {
+		t.Run(fmt.Sprintf("%U", ws), func(t *testing.T) {
+			doc := document.NewDocument()
+			err := doc.SetPadding([]rune{ws})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			doc.AppendLine(document.Fields("Name", "Motto")...)
+			doc.AppendLine(document.Fields("Åsa", "ready"+string(ws)+"set")...)
+			doc.AppendLine(document.Field("Zoë"), document.Null())
+			b, err := doc.WriteAll()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.ContainsRune(b, ws) {
+				t.Errorf("expected the output to be padded with %U", ws)
+			}
+			lines, err := reader.NewReader(bytes.NewReader(b)).ReadAll()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(lines) != 3 {
+				t.Errorf("expected 3 lines but got %d", len(lines))
+				return
+			}
+			if f, _ := lines[1].Field(0); f.Value != "Åsa" {
+				t.Errorf("expected Åsa but got %+v", f)
+			}
+			if f, _ := lines[1].Field(1); f.Value != "ready"+string(ws)+"set" {
+				t.Errorf("expected the motto to keep %U but got %+v", ws, f)
+			}
+			if f, _ := lines[2].Field(1); !f.IsNull {
+				t.Errorf("expected a null motto but got %+v", f)
+			}
+		})
+	}
+}
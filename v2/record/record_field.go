@@ -43,6 +43,11 @@ func (f *RecordField) SerializeText() string {
 		wrapped = true
 		v = fmt.Sprintf(`"%s"`, v)
 	}
+	// an unquoted # would start a comment
+	if strings.Contains(v, "#") && !wrapped {
+		wrapped = true
+		v = fmt.Sprintf(`"%s"`, v)
+	}
 	if strings.ContainsFunc(v, utils.IsFieldDelimiter) && !wrapped {
 		wrapped = true
 		v = fmt.Sprintf(`"%s"`, v)
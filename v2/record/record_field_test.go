@@ -29,3 +29,10 @@ func TestSerializeText(t *testing.T) {
 		t.Errorf("expect\n%s\nbut got\n%s\ninstead", exp2, out2)
 	}
 }
+
+func TestSerializeTextWithHashSign(t *testing.T) {
+	rec := record.RecordField{Value: "#ff0000"}
+	if rec.SerializeText() != `"#ff0000"` {
+		t.Errorf("expected the value to be quoted but got %s", rec.SerializeText())
+	}
+}
@@ -0,0 +1,152 @@
+package record
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNullValue      = errors.New("value is null")
+	ErrInvalidDecimal = errors.New("invalid decimal")
+)
+
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// A ValueError is returned when the value of a field is null or cannot be converted to the requested type
+type ValueError struct {
+	FieldName  string
+	FieldIndex int
+	RowIndex   int
+	Err        error
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("field %d (%s) on line %d: %v", e.FieldIndex, e.FieldName, e.RowIndex, e.Err)
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+func (f *RecordField) valueError(err error) error {
+	return &ValueError{FieldName: f.FieldName, FieldIndex: f.FieldIndex, RowIndex: f.RowIndex, Err: err}
+}
+
+// returns the value to convert or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) nonNull() (string, error) {
+	if f.IsNull {
+		return "", f.valueError(ErrNullValue)
+	}
+	return f.Value, nil
+}
+
+// Returns the value as an int, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Int() (int, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, f.valueError(err)
+	}
+	return i, nil
+}
+
+// Returns the value as an int64, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Int64() (int64, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, f.valueError(err)
+	}
+	return i, nil
+}
+
+// Returns the value as a float64, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Float64() (float64, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return 0, err
+	}
+	fl, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, f.valueError(err)
+	}
+	return fl, nil
+}
+
+// Returns the value as a bool, accepts the values of strconv.ParseBool, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Bool() (bool, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, f.valueError(err)
+	}
+	return b, nil
+}
+
+// Returns the value parsed with the time layout, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Time(layout string) (time.Time, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return time.Time{}, f.valueError(err)
+	}
+	return t, nil
+}
+
+// Returns the value parsed with time.ParseDuration, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Duration() (time.Duration, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, f.valueError(err)
+	}
+	return d, nil
+}
+
+// Returns the value as an integer of any size, or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) BigInt() (*big.Int, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return nil, err
+	}
+	i, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		return nil, f.valueError(fmt.Errorf("invalid integer %q", v))
+	}
+	return i, nil
+}
+
+// Returns the exact value of a decimal such as "12.50" or "-1.5e3", or an error wrapping ErrNullValue when the field is null
+func (f *RecordField) Decimal() (*big.Rat, error) {
+	v, err := f.nonNull()
+	if err != nil {
+		return nil, err
+	}
+	if !decimalPattern.MatchString(v) {
+		return nil, f.valueError(fmt.Errorf("%w %q", ErrInvalidDecimal, v))
+	}
+	d, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return nil, f.valueError(fmt.Errorf("%w %q", ErrInvalidDecimal, v))
+	}
+	return d, nil
+}
@@ -0,0 +1,59 @@
+package record_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+func TestTypedValues(t *testing.T) {
+	if v, err := (&record.RecordField{Value: "-42"}).Int(); err != nil || v != -42 {
+		t.Errorf("expected -42 but got %d %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "9007199254740993"}).Int64(); err != nil || v != 9007199254740993 {
+		t.Errorf("expected 9007199254740993 but got %d %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "1.25"}).Float64(); err != nil || v != 1.25 {
+		t.Errorf("expected 1.25 but got %f %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "true"}).Bool(); err != nil || !v {
+		t.Errorf("expected true but got %t %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "2024-07-02"}).Time(time.DateOnly); err != nil || !v.Equal(time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2024-07-02 but got %s %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "1h30m"}).Duration(); err != nil || v != 90*time.Minute {
+		t.Errorf("expected 1h30m but got %s %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "123456789012345678901234567890"}).BigInt(); err != nil || v.String() != "123456789012345678901234567890" {
+		t.Errorf("expected a big integer but got %s %v", v, err)
+	}
+	if v, err := (&record.RecordField{Value: "12.50"}).Decimal(); err != nil || v.FloatString(2) != "12.50" {
+		t.Errorf("expected 12.50 but got %s %v", v, err)
+	}
+	if _, err := (&record.RecordField{Value: "1/3"}).Decimal(); !errors.Is(err, record.ErrInvalidDecimal) {
+		t.Errorf("expected a fraction to be an invalid decimal but got %v", err)
+	}
+}
+
+func TestTypedValuesNull(t *testing.T) {
+	f := record.RecordField{IsNull: true, FieldName: "Age", RowIndex: 3, FieldIndex: 1}
+	_, err := f.Int()
+	if !errors.Is(err, record.ErrNullValue) {
+		t.Errorf("expected a null value error but got %v", err)
+	}
+	var valueErr *record.ValueError
+	if !errors.As(err, &valueErr) || valueErr.FieldName != "Age" || valueErr.RowIndex != 3 {
+		t.Errorf("expected the error to name the field but got %v", err)
+	}
+	if _, err := f.Time(time.DateOnly); !errors.Is(err, record.ErrNullValue) {
+		t.Errorf("expected a null value error but got %v", err)
+	}
+	_, err = (&record.RecordField{Value: "abc"}).Int()
+	if errors.Is(err, record.ErrNullValue) || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected a syntax error but got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Detect, decode and encode text in the encodings allowed by ReliableTXT, the text format WSV is built on.
+//
+// ReliableTXT identifies the encoding of a file by its byte order mark (BOM), data without a byte order
+// mark is read as UTF-8.
+package reliabletxt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidEncoding = errors.New("unknown ReliableTXT encoding")
+	ErrInvalidText     = errors.New("text is not valid for the encoding")
+	ErrTruncatedText   = errors.New("text ended in the middle of a character")
+)
+
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	UTF16BE
+	UTF16LE
+	UTF32BE
+	UTF32LE
+)
+
+func (enc Encoding) String() string {
+	switch enc {
+	case UTF8:
+		return "UTF-8"
+	case UTF16BE:
+		return "UTF-16BE"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF32BE:
+		return "UTF-32BE"
+	case UTF32LE:
+		return "UTF-32LE"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(enc))
+	}
+}
+
+// Returns the byte order mark identifying the encoding
+func (enc Encoding) Preamble() []byte {
+	switch enc {
+	case UTF8:
+		return []byte{0xEF, 0xBB, 0xBF}
+	case UTF16BE:
+		return []byte{0xFE, 0xFF}
+	case UTF16LE:
+		return []byte{0xFF, 0xFE}
+	case UTF32BE:
+		return []byte{0x00, 0x00, 0xFE, 0xFF}
+	case UTF32LE:
+		return []byte{0xFF, 0xFE, 0x00, 0x00}
+	default:
+		return nil
+	}
+}
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+func (enc Encoding) byteOrder() byteOrder {
+	if enc == UTF16LE || enc == UTF32LE {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// Returns the encoding identified by the byte order mark at the start of b and the length of the byte order mark.
+// Data without a byte order mark is UTF-8 with a length of 0.
+func DetectEncoding(b []byte) (Encoding, int) {
+	// UTF-32LE has to be checked before UTF-16LE since they share the first two bytes
+	for _, enc := range []Encoding{UTF32LE, UTF32BE, UTF8, UTF16BE, UTF16LE} {
+		bom := enc.Preamble()
+		if len(b) >= len(bom) && string(b[:len(bom)]) == string(bom) {
+			return enc, len(bom)
+		}
+	}
+	return UTF8, 0
+}
+
+// Decode detects the encoding of b, removes the byte order mark and returns the text as UTF-8
+func Decode(b []byte) ([]byte, Encoding, error) {
+	enc, n := DetectEncoding(b)
+	text, rest, err := decodeUnits(nil, b[n:], enc)
+	if err != nil {
+		return nil, enc, err
+	}
+	if len(rest) > 0 {
+		return nil, enc, ErrTruncatedText
+	}
+	return text, enc, nil
+}
+
+// Encode transcodes the UTF-8 text into the encoding prefixed by the byte order mark of the encoding
+func Encode(text []byte, enc Encoding) ([]byte, error) {
+	return AppendText(enc.Preamble(), text, enc)
+}
+
+// AppendText transcodes the UTF-8 text into the encoding without a byte order mark and appends it to dst
+func AppendText(dst []byte, text []byte, enc Encoding) ([]byte, error) {
+	order := enc.byteOrder()
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		if r == utf8.RuneError && size <= 1 {
+			return dst, ErrInvalidText
+		}
+		switch enc {
+		case UTF8:
+			dst = append(dst, text[:size]...)
+		case UTF16BE, UTF16LE:
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				dst = order.AppendUint16(dst, uint16(r1))
+				dst = order.AppendUint16(dst, uint16(r2))
+			} else {
+				dst = order.AppendUint16(dst, uint16(r))
+			}
+		case UTF32BE, UTF32LE:
+			dst = order.AppendUint32(dst, uint32(r))
+		default:
+			return dst, ErrInvalidEncoding
+		}
+		text = text[size:]
+	}
+	return dst, nil
+}
+
+// decodes as many complete characters of b as possible into dst, returns the bytes that do not form a complete character
+func decodeUnits(dst []byte, b []byte, enc Encoding) ([]byte, []byte, error) {
+	order := enc.byteOrder()
+	switch enc {
+	case UTF8:
+		for len(b) > 0 {
+			r, size := utf8.DecodeRune(b)
+			if r == utf8.RuneError && size <= 1 {
+				if !utf8.FullRune(b) {
+					return dst, b, nil
+				}
+				return dst, b, ErrInvalidText
+			}
+			dst = append(dst, b[:size]...)
+			b = b[size:]
+		}
+	case UTF16BE, UTF16LE:
+		for len(b) >= 2 {
+			r := rune(order.Uint16(b))
+			size := 2
+			if utf16.IsSurrogate(r) {
+				if len(b) < 4 {
+					return dst, b, nil
+				}
+				r = utf16.DecodeRune(r, rune(order.Uint16(b[2:])))
+				size = 4
+				if r == utf8.RuneError {
+					return dst, b, ErrInvalidText
+				}
+			}
+			dst = utf8.AppendRune(dst, r)
+			b = b[size:]
+		}
+	case UTF32BE, UTF32LE:
+		for len(b) >= 4 {
+			r := rune(order.Uint32(b))
+			if !utf8.ValidRune(r) {
+				return dst, b, ErrInvalidText
+			}
+			dst = utf8.AppendRune(dst, r)
+			b = b[4:]
+		}
+	default:
+		return dst, b, ErrInvalidEncoding
+	}
+	return dst, b, nil
+}
+
+// A Reader detects the encoding of the underlying reader on the first read,
+// strips the byte order mark and returns the text as UTF-8.
+type Reader struct {
+	r        *bufio.Reader
+	enc      Encoding
+	detected bool
+	// bytes read that do not form a complete character yet
+	in []byte
+	// decoded UTF-8 not yet returned to the caller
+	out []byte
+	err error
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Returns the detected encoding, UTF-8 until the first call to Read
+func (r *Reader) Encoding() Encoding {
+	return r.enc
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if !r.detected {
+		r.detected = true
+		bom, err := r.r.Peek(4)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		var n int
+		r.enc, n = DetectEncoding(bom)
+		r.r.Discard(n)
+	}
+	if r.enc == UTF8 && len(r.in) == 0 && len(r.out) == 0 {
+		return r.r.Read(p)
+	}
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *Reader) fill() {
+	chunk := make([]byte, 4096)
+	n, err := r.r.Read(chunk)
+	r.in = append(r.in, chunk[:n]...)
+	var decodeErr error
+	r.out, r.in, decodeErr = decodeUnits(r.out, r.in, r.enc)
+	if decodeErr != nil {
+		r.err = decodeErr
+		return
+	}
+	if err == io.EOF && len(r.in) > 0 {
+		err = ErrTruncatedText
+	}
+	r.err = err
+}
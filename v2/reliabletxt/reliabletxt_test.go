@@ -0,0 +1,84 @@
+package reliabletxt_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+)
+
+var encodings = []reliabletxt.Encoding{
+	reliabletxt.UTF8,
+	reliabletxt.UTF16BE,
+	reliabletxt.UTF16LE,
+	reliabletxt.UTF32BE,
+	reliabletxt.UTF32LE,
+}
+
+const sample = "Country Capital \"Emoji of Flag\"\nJapan Tokyo 🇯🇵　#ideographic space\n"
+
+func TestEncodeDecode(t *testing.T) {
+	for _, enc := range encodings {
+		t.Run(enc.String(), func(t *testing.T) {
+			b, err := reliabletxt.Encode([]byte(sample), enc)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.HasPrefix(b, enc.Preamble()) {
+				t.Errorf("expected the output to start with the byte order mark %x", enc.Preamble())
+			}
+			detected, n := reliabletxt.DetectEncoding(b)
+			if detected != enc || n != len(enc.Preamble()) {
+				t.Errorf("expected to detect %s (%d) but got %s (%d)", enc, len(enc.Preamble()), detected, n)
+			}
+			text, detected, err := reliabletxt.Decode(b)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if detected != enc || string(text) != sample {
+				t.Errorf("expected %s %q but got %s %q", enc, sample, detected, string(text))
+			}
+		})
+	}
+}
+
+func TestDecodeWithoutPreamble(t *testing.T) {
+	text, enc, err := reliabletxt.Decode([]byte(sample))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if enc != reliabletxt.UTF8 || string(text) != sample {
+		t.Errorf("expected UTF-8 %q but got %s %q", sample, enc, string(text))
+	}
+	_, _, err = reliabletxt.Decode([]byte{0xFE, 0xFF, 0x00})
+	if err != reliabletxt.ErrTruncatedText {
+		t.Errorf("expected a truncated text error but got %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	for _, enc := range encodings {
+		t.Run(enc.String(), func(t *testing.T) {
+			b, err := reliabletxt.Encode([]byte(sample), enc)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// read a byte at a time so characters are split across reads
+			r := reliabletxt.NewReader(iotest.OneByteReader(bytes.NewReader(b)))
+			text, err := io.ReadAll(r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if r.Encoding() != enc || string(text) != sample {
+				t.Errorf("expected %s %q but got %s %q", enc, sample, r.Encoding(), string(text))
+			}
+		})
+	}
+}
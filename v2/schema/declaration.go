@@ -0,0 +1,226 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/internetcalifornia/wsv/v2/utils"
+)
+
+// A schema declared in a comment starts with the prefix, e.g. `#@schema Id:int! Name:string Email:string?`
+const CommentPrefix = "@schema"
+
+var ErrInvalidDeclaration = errors.New("invalid schema declaration")
+
+// Parses a comment declaring a schema. Returns false if the comment does not start with CommentPrefix.
+func ParseComment(comment string) (*Schema, bool, error) {
+	decl, ok := strings.CutPrefix(comment, CommentPrefix)
+	if !ok {
+		return nil, false, nil
+	}
+	if r, _ := utf8.DecodeRuneInString(decl); decl != "" && !utils.IsFieldDelimiter(r) {
+		return nil, false, nil
+	}
+	s, err := Parse(decl)
+	return s, true, err
+}
+
+// Parses a whitespace separated list of column declarations.
+//
+// A column is declared as name:type followed by `?` if the column is nullable and `!` if its values are unique.
+// Names containing whitespace are double quoted like a WSV value. The types enum, regex and date take an argument:
+//
+//	Role:enum(admin|user) Code:regex([A-Z]{3}) "Date of Birth":date(02.01.2006)?
+func Parse(decl string) (*Schema, error) {
+	s := New()
+	i := 0
+	for {
+		for i < len(decl) {
+			r, size := utf8.DecodeRuneInString(decl[i:])
+			if !utils.IsFieldDelimiter(r) {
+				break
+			}
+			i += size
+		}
+		if i == len(decl) {
+			return s, nil
+		}
+		c, n, err := parseColumn(decl[i:])
+		if err != nil {
+			return nil, err
+		}
+		s.Columns = append(s.Columns, c)
+		i += n
+	}
+}
+
+// parses the column declared at the start of decl and returns the number of bytes consumed
+func parseColumn(decl string) (Column, int, error) {
+	var c Column
+	name, i, err := parseName(decl)
+	if err != nil {
+		return c, 0, err
+	}
+	c.Name = name
+	if i == len(decl) || decl[i] != ':' {
+		return c, 0, fmt.Errorf("%w: column %q has no type", ErrInvalidDeclaration, name)
+	}
+	i++
+	start := i
+	for i < len(decl) && isTypeNameByte(decl[i]) {
+		i++
+	}
+	typ, err := ParseType(decl[start:i])
+	if err != nil {
+		return c, 0, fmt.Errorf("%w: column %q: %w", ErrInvalidDeclaration, name, err)
+	}
+	c.Type = typ
+	hasArg := i < len(decl) && decl[i] == '('
+	arg := ""
+	if hasArg {
+		n := argLength(decl[i:])
+		if n < 0 {
+			return c, 0, fmt.Errorf("%w: column %q has an unterminated argument", ErrInvalidDeclaration, name)
+		}
+		arg = decl[i+1 : i+n-1]
+		i += n
+	}
+	switch {
+	case typ == Enum && hasArg:
+		c.Values = strings.Split(arg, "|")
+	case typ == Regex && hasArg:
+		c.Pattern, err = regexp.Compile(arg)
+		if err != nil {
+			return c, 0, fmt.Errorf("%w: column %q: %w", ErrInvalidDeclaration, name, err)
+		}
+	case typ == Date && hasArg:
+		c.Layout = arg
+	case typ == Enum || typ == Regex:
+		return c, 0, fmt.Errorf("%w: column %q of type %s needs an argument", ErrInvalidDeclaration, name, typ)
+	case hasArg:
+		return c, 0, fmt.Errorf("%w: column %q of type %s takes no argument", ErrInvalidDeclaration, name, typ)
+	}
+flags:
+	for i < len(decl) {
+		switch decl[i] {
+		case '?':
+			c.Nullable = true
+		case '!':
+			c.Unique = true
+		default:
+			break flags
+		}
+		i++
+	}
+	if r, _ := utf8.DecodeRuneInString(decl[i:]); i < len(decl) && !utils.IsFieldDelimiter(r) {
+		return c, 0, fmt.Errorf("%w: unexpected %q after column %q", ErrInvalidDeclaration, r, name)
+	}
+	return c, i, nil
+}
+
+func parseName(decl string) (string, int, error) {
+	if !strings.HasPrefix(decl, `"`) {
+		i := strings.IndexFunc(decl, func(r rune) bool { return r == ':' || utils.IsFieldDelimiter(r) })
+		if i < 0 {
+			i = len(decl)
+		}
+		if i == 0 {
+			return "", 0, fmt.Errorf("%w: column without a name", ErrInvalidDeclaration)
+		}
+		return decl[:i], i, nil
+	}
+	var name strings.Builder
+	for i := 1; i < len(decl); i++ {
+		if decl[i] != '"' {
+			name.WriteByte(decl[i])
+			continue
+		}
+		switch {
+		case strings.HasPrefix(decl[i:], `""`):
+			name.WriteByte('"')
+			i++
+		case strings.HasPrefix(decl[i:], `"/"`):
+			name.WriteByte('\n')
+			i += 2
+		default:
+			return name.String(), i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("%w: unterminated column name", ErrInvalidDeclaration)
+}
+
+// returns the length of the parenthesized argument at the start of decl, including the parentheses, or -1 if it is not terminated.
+// Nested parentheses are balanced and a backslash escapes the following byte.
+func argLength(decl string) int {
+	depth := 0
+	for i := 0; i < len(decl); i++ {
+		switch decl[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+func isTypeNameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Returns the declaration of the columns, as parsed by Parse
+func (s *Schema) String() string {
+	decls := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		decls[i] = c.String()
+	}
+	return strings.Join(decls, " ")
+}
+
+// Returns the schema declared as a comment, without the leading `#`
+func (s *Schema) Comment() string {
+	return CommentPrefix + " " + s.String()
+}
+
+// Returns the declaration of the column, as parsed by Parse
+func (c Column) String() string {
+	var b strings.Builder
+	b.WriteString(quoteName(c.Name))
+	b.WriteByte(':')
+	b.WriteString(c.Type.String())
+	switch {
+	case c.Type == Enum:
+		b.WriteString("(" + strings.Join(c.Values, "|") + ")")
+	case c.Type == Regex && c.Pattern != nil:
+		b.WriteString("(" + c.Pattern.String() + ")")
+	case c.Type == Date && c.Layout != "" && c.Layout != time.DateOnly:
+		b.WriteString("(" + c.Layout + ")")
+	}
+	if c.Nullable {
+		b.WriteByte('?')
+	}
+	if c.Unique {
+		b.WriteByte('!')
+	}
+	return b.String()
+}
+
+func quoteName(name string) string {
+	if name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return r == '"' || r == ':' || r == '\n' || utils.IsFieldDelimiter(r)
+	}) {
+		return name
+	}
+	name = strings.ReplaceAll(name, `"`, `""`)
+	name = strings.ReplaceAll(name, "\n", `"/"`)
+	return `"` + name + `"`
+}
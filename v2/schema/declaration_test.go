@@ -0,0 +1,66 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/schema"
+)
+
+func TestParse(t *testing.T) {
+	s, err := schema.Parse(`Id:int! Name:string "Date of Birth":date(02.01.2006)? Role:enum(admin|user) Code:regex(^(A|B)\)$)? Score:float`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(s.Columns) != 6 {
+		t.Errorf("expected 6 columns but got %d", len(s.Columns))
+		return
+	}
+	id := s.Columns[0]
+	if id.Name != "Id" || id.Type != schema.Int || !id.Unique || id.Nullable {
+		t.Errorf("expected a unique int column Id but got %+v", id)
+	}
+	born := s.Columns[2]
+	if born.Name != "Date of Birth" || born.Type != schema.Date || born.Layout != "02.01.2006" || !born.Nullable {
+		t.Errorf("expected a nullable date column Date of Birth but got %+v", born)
+	}
+	role := s.Columns[3]
+	if len(role.Values) != 2 || role.Values[1] != "user" {
+		t.Errorf("expected the values admin and user but got %q", role.Values)
+	}
+	code := s.Columns[4]
+	if code.Pattern == nil || !code.Pattern.MatchString("B)") || !code.Nullable {
+		t.Errorf("expected a nullable regex column but got %+v", code)
+	}
+
+	reparsed, err := schema.Parse(s.String())
+	if err != nil || reparsed.String() != s.String() {
+		t.Errorf("expected the declaration %q to round trip but got %q %v", s.String(), reparsed, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, decl := range []string{"Age", "Age:", "Age:uuid", "Age:int(3)", "Role:enum", "Code:regex(a", `"Name:string`, "Age:int?x", "Code:regex([)"} {
+		_, err := schema.Parse(decl)
+		if !errors.Is(err, schema.ErrInvalidDeclaration) {
+			t.Errorf("expected %q to be an invalid declaration but got %v", decl, err)
+		}
+	}
+}
+
+func TestParseComment(t *testing.T) {
+	s, ok, err := schema.ParseComment("@schema Age:int Email:string?")
+	if !ok || err != nil || len(s.Columns) != 2 {
+		t.Errorf("expected a schema with 2 columns but got %v %v", ok, err)
+	}
+	if _, ok, _ := schema.ParseComment("@schemas Age:int"); ok {
+		t.Error("expected a comment with a different prefix to be ignored")
+	}
+	if _, ok, _ := schema.ParseComment(" just a comment"); ok {
+		t.Error("expected a regular comment to be ignored")
+	}
+	if s.Comment() != "@schema Age:int Email:string?" {
+		t.Errorf("expected the comment to round trip but got %q", s.Comment())
+	}
+}
@@ -0,0 +1,279 @@
+// Declare the columns of a tabular WSV document and validate lines against them.
+//
+// Lines of a reader.Reader and a document.Document can both be validated:
+//
+//	err := schema.Validate(s, r.All())
+//	err := schema.ValidateLines(s, doc.Lines())
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/internetcalifornia/wsv/v2/record"
+)
+
+var (
+	ErrMissingColumn   = errors.New("column is missing from the header")
+	ErrNotNullable     = errors.New("value cannot be null")
+	ErrDuplicateValue  = errors.New("value must be unique")
+	ErrInvalidValue    = errors.New("value does not match the column type")
+	ErrNotInEnum       = errors.New("value is not one of the allowed values")
+	ErrPatternMismatch = errors.New("value does not match the pattern")
+	ErrUnknownType     = errors.New("unknown column type")
+)
+
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	Date
+	Enum
+	Regex
+)
+
+var typeNames = []string{"string", "int", "float", "bool", "date", "enum", "regex"}
+
+func (t Type) String() string {
+	if int(t) < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+	return typeNames[t]
+}
+
+// Returns the type with the name, as returned by Type.String
+func ParseType(name string) (Type, error) {
+	i := slices.Index(typeNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("%w %q", ErrUnknownType, name)
+	}
+	return Type(i), nil
+}
+
+type Column struct {
+	Name     string
+	Type     Type
+	Nullable bool
+	// every non-null value of the column must be different
+	Unique bool
+	// layout of Date values, defaults to time.DateOnly
+	Layout string
+	// allowed values of an Enum column
+	Values []string
+	// pattern every value of a Regex column must match
+	Pattern *regexp.Regexp
+}
+
+type Schema struct {
+	Columns []Column
+}
+
+func New(columns ...Column) *Schema {
+	return &Schema{Columns: columns}
+}
+
+// Returns the column with the name
+func (s *Schema) Column(name string) (*Column, bool) {
+	for i := range s.Columns {
+		if s.Columns[i].Name == name {
+			return &s.Columns[i], true
+		}
+	}
+	return nil, false
+}
+
+// A Violation of the schema, line numbers are 1-indexed
+type Violation struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("line %d, column %q: %v", v.Line, v.Column, v.Err)
+}
+
+func (v Violation) Unwrap() error {
+	return v.Err
+}
+
+// A ValidationError lists every violation found
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i, v := range e.Violations {
+		errs[i] = v
+	}
+	return errs
+}
+
+// Line is implemented by reader.ReaderLine and document.DocumentLine
+type Line interface {
+	LineNumber() int
+	FieldCount() int
+	Field(fieldIndex int) (*record.RecordField, error)
+}
+
+// A Validator validates lines one at a time, remembering the values of unique columns across lines
+type Validator struct {
+	schema     *Schema
+	seen       map[string]map[string]int
+	violations []Violation
+}
+
+func (s *Schema) NewValidator() *Validator {
+	return &Validator{
+		schema:     s,
+		seen:       make(map[string]map[string]int),
+		violations: make([]Violation, 0),
+	}
+}
+
+// Validates the line and returns its violations.
+//
+// A header line is checked for the columns of the schema, the fields of other lines are validated
+// against the column with the same name as the field.
+func (v *Validator) ValidateLine(line Line) []Violation {
+	found := make([]Violation, 0)
+	first, err := line.Field(0)
+	if err != nil {
+		return found
+	}
+	if first.IsHeader {
+		names := make([]string, line.FieldCount())
+		for i := range line.FieldCount() {
+			if f, err := line.Field(i); err == nil {
+				names[i] = f.Value
+			}
+		}
+		for _, c := range v.schema.Columns {
+			if !slices.Contains(names, c.Name) {
+				found = append(found, Violation{Line: line.LineNumber(), Column: c.Name, Err: ErrMissingColumn})
+			}
+		}
+	} else {
+		for i := range line.FieldCount() {
+			f, err := line.Field(i)
+			if err != nil {
+				continue
+			}
+			c, ok := v.schema.Column(f.FieldName)
+			if !ok {
+				continue
+			}
+			err = v.validateField(c, f, line.LineNumber())
+			if err != nil {
+				found = append(found, Violation{Line: line.LineNumber(), Column: c.Name, Value: f.Value, Err: err})
+			}
+		}
+	}
+	v.violations = append(v.violations, found...)
+	return found
+}
+
+// Returns a *ValidationError with every violation found so far or nil if there are none
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+func (v *Validator) validateField(c *Column, f *record.RecordField, lineNumber int) error {
+	if f.IsNull {
+		if !c.Nullable {
+			return ErrNotNullable
+		}
+		return nil
+	}
+	err := c.validateValue(f)
+	if err != nil {
+		return err
+	}
+	if !c.Unique {
+		return nil
+	}
+	seen, ok := v.seen[c.Name]
+	if !ok {
+		seen = make(map[string]int)
+		v.seen[c.Name] = seen
+	}
+	if ln, ok := seen[f.Value]; ok {
+		return fmt.Errorf("%w, %q first seen on line %d", ErrDuplicateValue, f.Value, ln)
+	}
+	seen[f.Value] = lineNumber
+	return nil
+}
+
+func (c *Column) validateValue(f *record.RecordField) error {
+	var err error
+	switch c.Type {
+	case Int:
+		_, err = f.Int64()
+	case Float:
+		_, err = f.Float64()
+	case Bool:
+		_, err = f.Bool()
+	case Date:
+		layout := c.Layout
+		if layout == "" {
+			layout = time.DateOnly
+		}
+		_, err = f.Time(layout)
+	case Enum:
+		if !slices.Contains(c.Values, f.Value) {
+			return fmt.Errorf("%w %q", ErrNotInEnum, c.Values)
+		}
+	case Regex:
+		if c.Pattern != nil && !c.Pattern.MatchString(f.Value) {
+			return fmt.Errorf("%w %s", ErrPatternMismatch, c.Pattern)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w %s", ErrInvalidValue, c.Type)
+	}
+	return nil
+}
+
+// Validates every line of the sequence, such as the lines of reader.Reader.All().
+// An error yielded by the sequence is returned as is, otherwise a *ValidationError listing every violation or nil
+func Validate[L Line](s *Schema, lines iter.Seq2[L, error]) error {
+	v := s.NewValidator()
+	for line, err := range lines {
+		if err != nil {
+			return err
+		}
+		v.ValidateLine(line)
+	}
+	return v.Err()
+}
+
+// Validates every line, such as the lines of document.Document.Lines().
+// Returns a *ValidationError listing every violation or nil
+func ValidateLines[L Line](s *Schema, lines []L) error {
+	v := s.NewValidator()
+	for _, line := range lines {
+		v.ValidateLine(line)
+	}
+	return v.Err()
+}
@@ -0,0 +1,88 @@
+package schema_test
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/schema"
+)
+
+var people = schema.New(
+	schema.Column{Name: "Id", Type: schema.Int, Unique: true},
+	schema.Column{Name: "Name", Type: schema.String},
+	schema.Column{Name: "Born", Type: schema.Date, Nullable: true},
+	schema.Column{Name: "Role", Type: schema.Enum, Values: []string{"admin", "user"}},
+	schema.Column{Name: "Email", Type: schema.Regex, Pattern: regexp.MustCompile(`^[^@]+@[^@]+$`), Nullable: true},
+)
+
+func TestValidateReader(t *testing.T) {
+	data := []byte(`Id Name Born Role Email
+1 Scott 1990-01-02 admin scott@example.com
+2 Jane - user -
+2 - 02/03/1990 guest jane
+x Bob 1990-01-02 user -
+`)
+	r := reader.NewReader(bytes.NewReader(data))
+	err := schema.Validate(people, r.All())
+	var validationErr *schema.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected a validation error but got %v", err)
+		return
+	}
+	exp := []struct {
+		line   int
+		column string
+		err    error
+	}{
+		{4, "Id", schema.ErrDuplicateValue},
+		{4, "Name", schema.ErrNotNullable},
+		{4, "Born", schema.ErrInvalidValue},
+		{4, "Role", schema.ErrNotInEnum},
+		{4, "Email", schema.ErrPatternMismatch},
+		{5, "Id", schema.ErrInvalidValue},
+	}
+	if len(validationErr.Violations) != len(exp) {
+		t.Errorf("expected %d violations but got %d\n%v", len(exp), len(validationErr.Violations), err)
+		return
+	}
+	for i, v := range validationErr.Violations {
+		if v.Line != exp[i].line || v.Column != exp[i].column || !errors.Is(v, exp[i].err) {
+			t.Errorf("expected violation %d to be %v on line %d column %s but got %v", i, exp[i].err, exp[i].line, exp[i].column, v)
+		}
+	}
+	if !errors.Is(err, schema.ErrNotInEnum) {
+		t.Error("expected the validation error to wrap every violation")
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	doc := document.NewDocument()
+	doc.AppendLine(document.Fields("Id", "Name", "Role")...)
+	doc.AppendLine(document.Fields("1", "Scott", "admin")...)
+	if err := schema.ValidateLines(people, doc.Lines()); err == nil {
+		t.Error("expected the missing columns to be reported")
+	} else if !errors.Is(err, schema.ErrMissingColumn) {
+		t.Errorf("expected a missing column but got %v", err)
+	}
+
+	s := schema.New(schema.Column{Name: "Id", Type: schema.Int}, schema.Column{Name: "Role", Type: schema.Enum, Values: []string{"admin"}})
+	if err := schema.ValidateLines(s, doc.Lines()); err != nil {
+		t.Errorf("expected the document to be valid but got %v", err)
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for _, typ := range []schema.Type{schema.String, schema.Int, schema.Float, schema.Bool, schema.Date, schema.Enum, schema.Regex} {
+		parsed, err := schema.ParseType(typ.String())
+		if err != nil || parsed != typ {
+			t.Errorf("expected %s but got %s %v", typ, parsed, err)
+		}
+	}
+	if _, err := schema.ParseType("uuid"); !errors.Is(err, schema.ErrUnknownType) {
+		t.Errorf("expected an unknown type but got %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+// Parse and write the Simple Markup Language (SML), a hierarchical format whose lines are WSV lines.
+//
+//	Configuration
+//		Video
+//			Resolution 1280 720
+//			RefreshRate 60
+//		End
+//	End
+//
+// A line with a single value begins an element or, when it matches the end keyword, ends the current element.
+// A line with more than one value is an attribute, the first value is its name followed by its values.
+package sml
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/record"
+	"github.com/internetcalifornia/wsv/v2/reliabletxt"
+)
+
+var (
+	ErrNoRootElement         = errors.New("document does not have a root element")
+	ErrOnlyOneRootElement    = errors.New("only one root element is allowed")
+	ErrElementNotClosed      = errors.New("element is not closed")
+	ErrUnexpectedEnd         = errors.New("end keyword without an open element")
+	ErrNullElementName       = errors.New("element name cannot be null")
+	ErrNullAttributeName     = errors.New("attribute name cannot be null")
+	ErrAttributeOutsideRoot  = errors.New("attributes must be inside the root element")
+	ErrNameIsEndKeyword      = errors.New("element name cannot be the end keyword")
+	ErrAttributeWithoutValue = errors.New("attribute must have at least one value")
+)
+
+const DefaultEndKeyword = "End"
+
+// A ParseError is returned for parsing errors, line numbers are 1-indexed
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("sml parse error on line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// A Node is an *Element, an *Attribute or an *EmptyNode
+type Node interface {
+	isNode()
+}
+
+type Element struct {
+	Name  string
+	Nodes []Node
+	// comment on the line beginning the element
+	Comment string
+	// comment on the line ending the element
+	EndComment string
+}
+
+type Attribute struct {
+	Name   string
+	Values []record.RecordField
+	// comment on the line of the attribute
+	Comment string
+}
+
+// An empty line or a line with only a comment
+type EmptyNode struct {
+	Comment string
+}
+
+func (*Element) isNode()   {}
+func (*Attribute) isNode() {}
+func (*EmptyNode) isNode() {}
+
+type Document struct {
+	Root *Element
+	// Keyword closing an element, compared case-insensitively when parsing, defaults to DefaultEndKeyword
+	EndKeyword string
+	// Close elements with the null value `-` instead of the EndKeyword
+	NullEndKeyword bool
+	// Runes written once per level of nesting, defaults to a tab
+	Indentation string
+	// empty lines and comments before the root element
+	Leading []*EmptyNode
+	// empty lines and comments after the root element
+	Trailing []*EmptyNode
+}
+
+func NewDocument(rootName string) *Document {
+	return &Document{
+		Root:        NewElement(rootName),
+		EndKeyword:  DefaultEndKeyword,
+		Indentation: "\t",
+	}
+}
+
+func NewElement(name string) *Element {
+	return &Element{Name: name, Nodes: make([]Node, 0)}
+}
+
+// Appends a child element and returns it
+func (e *Element) AddElement(name string) *Element {
+	child := NewElement(name)
+	e.Nodes = append(e.Nodes, child)
+	return child
+}
+
+// Appends an attribute with the values and returns it
+func (e *Element) AddAttribute(name string, values ...string) *Attribute {
+	attr := &Attribute{Name: name, Values: make([]record.RecordField, len(values))}
+	for i, v := range values {
+		attr.Values[i] = record.RecordField{Value: v, FieldIndex: i + 1}
+	}
+	e.Nodes = append(e.Nodes, attr)
+	return attr
+}
+
+// Returns the child elements with the name, compared case-insensitively
+func (e *Element) Elements(name string) []*Element {
+	elements := make([]*Element, 0)
+	for _, n := range e.Nodes {
+		if child, ok := n.(*Element); ok && strings.EqualFold(child.Name, name) {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+// Returns the first child element with the name or nil if there is none
+func (e *Element) Element(name string) *Element {
+	elements := e.Elements(name)
+	if len(elements) == 0 {
+		return nil
+	}
+	return elements[0]
+}
+
+// Returns the attributes with the name, compared case-insensitively
+func (e *Element) Attributes(name string) []*Attribute {
+	attributes := make([]*Attribute, 0)
+	for _, n := range e.Nodes {
+		if attr, ok := n.(*Attribute); ok && strings.EqualFold(attr.Name, name) {
+			attributes = append(attributes, attr)
+		}
+	}
+	return attributes
+}
+
+// Returns the first attribute with the name or nil if there is none
+func (e *Element) Attribute(name string) *Attribute {
+	attributes := e.Attributes(name)
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes[0]
+}
+
+// Parses an SML document in any ReliableTXT encoding.
+//
+// The end keyword is taken from the last line with a value, so documents using another keyword than "End",
+// or the null end keyword, are detected automatically.
+func Parse(data []byte) (*Document, error) {
+	text, _, err := reliabletxt.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+	lines := bytes.Split(bytes.TrimSuffix(text, []byte("\n")), []byte("\n"))
+
+	parsed := make([][]reader.LineField, len(lines))
+	for i, line := range lines {
+		fields, err := reader.ParseLine(i+1, line)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = fields
+	}
+
+	doc := &Document{Indentation: "\t", EndKeyword: DefaultEndKeyword}
+	for i := len(parsed) - 1; i >= 0; i-- {
+		values, _ := splitComment(parsed[i])
+		if len(values) == 0 {
+			continue
+		}
+		if values[0].IsNull {
+			doc.NullEndKeyword = true
+		} else {
+			doc.EndKeyword = values[0].Value
+		}
+		break
+	}
+
+	stack := make([]*Element, 0)
+	for i, fields := range parsed {
+		n := i + 1
+		values, comment := splitComment(fields)
+		switch {
+		case len(values) == 0:
+			empty := &EmptyNode{Comment: comment}
+			if len(stack) > 0 {
+				stack[len(stack)-1].Nodes = append(stack[len(stack)-1].Nodes, empty)
+			} else if doc.Root == nil {
+				doc.Leading = append(doc.Leading, empty)
+			} else {
+				doc.Trailing = append(doc.Trailing, empty)
+			}
+		case len(values) == 1 && doc.isEndKeyword(values[0]):
+			if len(stack) == 0 {
+				return nil, &ParseError{Line: n, Err: ErrUnexpectedEnd}
+			}
+			stack[len(stack)-1].EndComment = comment
+			stack = stack[:len(stack)-1]
+		case len(values) == 1:
+			if values[0].IsNull {
+				return nil, &ParseError{Line: n, Err: ErrNullElementName}
+			}
+			element := NewElement(values[0].Value)
+			element.Comment = comment
+			if len(stack) > 0 {
+				stack[len(stack)-1].Nodes = append(stack[len(stack)-1].Nodes, element)
+			} else if doc.Root == nil {
+				doc.Root = element
+			} else {
+				return nil, &ParseError{Line: n, Err: ErrOnlyOneRootElement}
+			}
+			stack = append(stack, element)
+		default:
+			if values[0].IsNull {
+				return nil, &ParseError{Line: n, Err: ErrNullAttributeName}
+			}
+			if len(stack) == 0 {
+				return nil, &ParseError{Line: n, Err: ErrAttributeOutsideRoot}
+			}
+			attr := &Attribute{Name: values[0].Value, Values: make([]record.RecordField, 0, len(values)-1), Comment: comment}
+			for fi, v := range values[1:] {
+				attr.Values = append(attr.Values, record.RecordField{Value: v.Value, IsNull: v.IsNull, FieldIndex: fi + 1, RowIndex: n})
+			}
+			stack[len(stack)-1].Nodes = append(stack[len(stack)-1].Nodes, attr)
+		}
+	}
+	if doc.Root == nil {
+		return nil, ErrNoRootElement
+	}
+	if len(stack) > 0 {
+		return nil, &ParseError{Line: len(lines), Err: ErrElementNotClosed}
+	}
+	return doc, nil
+}
+
+// separates the data values of a parsed line from its comment
+func splitComment(fields []reader.LineField) ([]reader.LineField, string) {
+	values := make([]reader.LineField, 0, len(fields))
+	comment := ""
+	for _, f := range fields {
+		if f.IsComment {
+			comment = f.Value
+			continue
+		}
+		values = append(values, f)
+	}
+	return values, comment
+}
+
+func (doc *Document) isEndKeyword(f reader.LineField) bool {
+	if doc.NullEndKeyword {
+		return f.IsNull
+	}
+	return !f.IsNull && strings.EqualFold(f.Value, doc.EndKeyword)
+}
+
+// Serialize writes the document with each level of nesting indented by the Indentation
+func (doc *Document) Serialize() ([]byte, error) {
+	if doc.Root == nil {
+		return nil, ErrNoRootElement
+	}
+	buf := make([]byte, 0)
+	for _, n := range doc.Leading {
+		buf = doc.appendEmpty(buf, n, 0)
+	}
+	buf, err := doc.appendElement(buf, doc.Root, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range doc.Trailing {
+		buf = doc.appendEmpty(buf, n, 0)
+	}
+	return buf, nil
+}
+
+func (doc *Document) endKeyword() string {
+	if doc.NullEndKeyword {
+		return "-"
+	}
+	return serializeName(cmp.Or(doc.EndKeyword, DefaultEndKeyword))
+}
+
+func (doc *Document) appendElement(buf []byte, e *Element, depth int) ([]byte, error) {
+	if !doc.NullEndKeyword && strings.EqualFold(e.Name, cmp.Or(doc.EndKeyword, DefaultEndKeyword)) {
+		return buf, fmt.Errorf("%w %q", ErrNameIsEndKeyword, e.Name)
+	}
+	buf = doc.appendLine(buf, depth, []string{serializeName(e.Name)}, e.Comment)
+	for _, n := range e.Nodes {
+		switch n := n.(type) {
+		case *Element:
+			var err error
+			buf, err = doc.appendElement(buf, n, depth+1)
+			if err != nil {
+				return buf, err
+			}
+		case *Attribute:
+			if len(n.Values) == 0 {
+				return buf, fmt.Errorf("%w %q", ErrAttributeWithoutValue, n.Name)
+			}
+			vals := make([]string, 0, len(n.Values)+1)
+			vals = append(vals, serializeName(n.Name))
+			for _, v := range n.Values {
+				vals = append(vals, v.SerializeText())
+			}
+			buf = doc.appendLine(buf, depth+1, vals, n.Comment)
+		case *EmptyNode:
+			buf = doc.appendEmpty(buf, n, depth+1)
+		}
+	}
+	buf = doc.appendLine(buf, depth, []string{doc.endKeyword()}, e.EndComment)
+	return buf, nil
+}
+
+func (doc *Document) appendEmpty(buf []byte, n *EmptyNode, depth int) []byte {
+	if len(n.Comment) == 0 {
+		return append(buf, '\n')
+	}
+	return doc.appendLine(buf, depth, nil, n.Comment)
+}
+
+func (doc *Document) appendLine(buf []byte, depth int, vals []string, comment string) []byte {
+	buf = append(buf, strings.Repeat(doc.Indentation, depth)...)
+	buf = append(buf, strings.Join(vals, " ")...)
+	if len(comment) > 0 {
+		if len(vals) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, '#')
+		buf = append(buf, comment...)
+	}
+	return append(buf, '\n')
+}
+
+func serializeName(name string) string {
+	f := record.RecordField{Value: name}
+	return f.SerializeText()
+}
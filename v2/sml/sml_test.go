@@ -0,0 +1,136 @@
+package sml_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/sml"
+)
+
+const config = `# application settings
+Configuration
+	Video #display settings
+		Resolution 1280 720
+		RefreshRate 60
+		Fullscreen true
+	End
+
+	Audio
+		Volume 100
+		Music -
+		Theme "Main Menu" "#ff0000"
+	End
+	Player
+		Name "Hero 123"
+	End
+End
+`
+
+func TestParse(t *testing.T) {
+	doc, err := sml.Parse([]byte(config))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if doc.Root.Name != "Configuration" || doc.EndKeyword != "End" || doc.NullEndKeyword {
+		t.Errorf("unexpected root %s and end keyword %s", doc.Root.Name, doc.EndKeyword)
+	}
+	if len(doc.Leading) != 1 || doc.Leading[0].Comment != " application settings" {
+		t.Errorf("expected the leading comment to be kept but got %+v", doc.Leading)
+	}
+	video := doc.Root.Element("video")
+	if video == nil || video.Comment != "display settings" {
+		t.Errorf("expected the Video element with a comment but got %+v", video)
+		return
+	}
+	res := video.Attribute("Resolution")
+	if res == nil || len(res.Values) != 2 || res.Values[0].Value != "1280" || res.Values[1].Value != "720" {
+		t.Errorf("unexpected resolution %+v", res)
+	}
+	audio := doc.Root.Element("Audio")
+	if audio == nil {
+		t.Error("expected the Audio element")
+		return
+	}
+	if music := audio.Attribute("Music"); music == nil || !music.Values[0].IsNull {
+		t.Errorf("expected music to be null but got %+v", music)
+	}
+	if theme := audio.Attribute("Theme"); theme == nil || theme.Values[0].Value != "Main Menu" || theme.Values[1].Value != "#ff0000" {
+		t.Errorf("unexpected theme %+v", theme)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc, err := sml.Parse([]byte(config))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := doc.Serialize()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(b) != config {
+		t.Errorf("expected the document to round trip\n%s\nbut got\n%s", config, string(b))
+	}
+}
+
+func TestNullEndKeyword(t *testing.T) {
+	doc := sml.NewDocument("Root")
+	doc.NullEndKeyword = true
+	doc.Indentation = "  "
+	doc.Root.AddElement("Child").AddAttribute("Name", "first value", "")
+	b, err := doc.Serialize()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	exp := "Root\n  Child\n    Name \"first value\" \"\"\n  -\n-\n"
+	if string(b) != exp {
+		t.Errorf("expected\n%s\nbut got\n%s", exp, string(b))
+	}
+	parsed, err := sml.Parse(b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !parsed.NullEndKeyword || parsed.Root.Element("Child").Attribute("Name").Values[1].Value != "" {
+		t.Errorf("unexpected document %+v", parsed)
+	}
+}
+
+func TestCustomEndKeyword(t *testing.T) {
+	data := "Root\n\tChild\n\t\tKey Value\n\tende\nEnde\n"
+	doc, err := sml.Parse([]byte(data))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if doc.EndKeyword != "Ende" {
+		t.Errorf("expected the end keyword Ende but got %s", doc.EndKeyword)
+	}
+	doc.Root.AddElement("Ende")
+	_, err = doc.Serialize()
+	if !errors.Is(err, sml.ErrNameIsEndKeyword) {
+		t.Errorf("expected an element named like the end keyword to fail but got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]error{
+		"Root\n\tChild\nEnd\n":    sml.ErrElementNotClosed,
+		"Root\nEnd\nOther\nEnd\n": sml.ErrOnlyOneRootElement,
+		"Root\n\t- value\nEnd\n":  sml.ErrNullAttributeName,
+		"Key value\nRoot\nEnd\n":  sml.ErrAttributeOutsideRoot,
+		strings.Repeat("\n", 3):   sml.ErrNoRootElement,
+		"Root\nEnd\nEnd\n":        sml.ErrUnexpectedEnd,
+	}
+	for data, exp := range tests {
+		_, err := sml.Parse([]byte(data))
+		if !errors.Is(err, exp) {
+			t.Errorf("expected %v for %q but got %v", exp, data, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// A database/sql driver for the WSV files of a directory, registered as "wsv".
+//
+// Every .wsv file of the directory is a table named after the file, its columns are the headers of the file.
+// Queries are read only and support the subset of SQL of the query package, `?` placeholders are replaced by
+// the arguments. Null values are returned as SQL NULL and every other value as a string.
+//
+//	db, err := sql.Open("wsv", "dir=/data")
+//	rows, err := db.Query("SELECT Country, Capital FROM countries WHERE Population > ? ORDER BY Country", 1000000)
+package sqldriver
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/internetcalifornia/wsv/v2/document"
+	"github.com/internetcalifornia/wsv/v2/query"
+	"github.com/internetcalifornia/wsv/v2/reader"
+)
+
+var (
+	ErrReadOnly         = errors.New("wsv: the driver is read only")
+	ErrInvalidDSN       = errors.New("wsv: invalid data source name")
+	ErrInvalidTable     = errors.New("wsv: table names cannot contain a path")
+	ErrUnsupportedValue = errors.New("wsv: unsupported argument type")
+)
+
+func init() {
+	sql.Register("wsv", &Driver{})
+}
+
+type Driver struct{}
+
+// Open returns a connection to the directory of the data source name, which is a whitespace separated list of key=value pairs.
+// The only key is dir, the directory of the WSV files.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	dir := ""
+	for _, pair := range strings.Fields(dsn) {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || key != "dir" {
+			return nil, fmt.Errorf("%w %q", ErrInvalidDSN, dsn)
+		}
+		dir = val
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("%w %q, dir is required", ErrInvalidDSN, dsn)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w, %s is not a directory", ErrInvalidDSN, dir)
+	}
+	return &conn{dir: dir}, nil
+}
+
+type conn struct {
+	dir string
+}
+
+func (c *conn) Prepare(q string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: q, numInput: countPlaceholders(q)}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, ErrReadOnly
+}
+
+// opens the WSV file of the table, with or without the .wsv extension
+func (c *conn) open(table string) (*os.File, error) {
+	if table != filepath.Base(table) || table == "." || table == ".." {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTable, table)
+	}
+	if filepath.Ext(table) != ".wsv" {
+		table += ".wsv"
+	}
+	return os.Open(filepath.Join(c.dir, table))
+}
+
+type stmt struct {
+	conn     *conn
+	query    string
+	numInput int
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, ErrReadOnly
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	text, err := bind(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	q, err := query.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.conn.open(q.From)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	doc, err := q.Run(reader.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	return &rows{columns: doc.Headers(), lines: doc.Lines()}, nil
+}
+
+type rows struct {
+	columns []string
+	lines   []document.DocumentLine
+	// index of the next line, the header line is line 0
+	next int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	r.lines = nil
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	r.next++
+	if r.next >= len(r.lines) {
+		return io.EOF
+	}
+	for i, field := range r.lines[r.next].Fields() {
+		if i >= len(dest) {
+			break
+		}
+		if field.IsNull {
+			dest[i] = nil
+			continue
+		}
+		dest[i] = field.Value
+	}
+	return nil
+}
+
+// calls fn with the index of every `?` that is not in a quoted string or name
+func eachPlaceholder(q string, fn func(i int)) {
+	var quote byte
+	for i := 0; i < len(q); i++ {
+		switch {
+		case quote != 0:
+			if q[i] == quote {
+				quote = 0
+			}
+		case q[i] == '\'' || q[i] == '"':
+			quote = q[i]
+		case q[i] == '?':
+			fn(i)
+		}
+	}
+}
+
+func countPlaceholders(q string) int {
+	n := 0
+	eachPlaceholder(q, func(int) { n++ })
+	return n
+}
+
+// replaces the placeholders of the query with the arguments as literals
+func bind(q string, args []driver.Value) (string, error) {
+	var b strings.Builder
+	last := 0
+	n := 0
+	var err error
+	eachPlaceholder(q, func(i int) {
+		if err != nil {
+			return
+		}
+		if n >= len(args) {
+			err = fmt.Errorf("wsv: expected %d arguments but got %d", countPlaceholders(q), len(args))
+			return
+		}
+		var lit string
+		lit, err = literal(args[n])
+		b.WriteString(q[last:i])
+		b.WriteString(lit)
+		last = i + 1
+		n++
+	})
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(q[last:])
+	return b.String(), nil
+}
+
+// returns the argument as a number or a single quoted string
+func literal(v driver.Value) (string, error) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("%w %v", ErrUnsupportedValue, v)
+		}
+		// written without an exponent, query numbers have no signed exponents
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return quote(strconv.FormatBool(v)), nil
+	case string:
+		return quote(v), nil
+	case []byte:
+		return quote(string(v)), nil
+	case time.Time:
+		return quote(v.Format(time.RFC3339Nano)), nil
+	}
+	return "", fmt.Errorf("%w %T, use IS NULL to match nulls", ErrUnsupportedValue, v)
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,122 @@
+package sqldriver_test
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/internetcalifornia/wsv/v2/reader"
+	"github.com/internetcalifornia/wsv/v2/sqldriver"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dir := t.TempDir()
+	data := "Country Capital Population\nJapan Tokyo 125700000\nIceland Reykjavik 372000\n\"O'Land\" - 1\nIndia \"New Delhi\" 1428600000\n"
+	if err := os.WriteFile(filepath.Join(dir, "countries.wsv"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("wsv", "dir="+dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestQuery(t *testing.T) {
+	db := openDB(t)
+	rows, err := db.Query("SELECT Country, Capital FROM countries WHERE Population > ? ORDER BY Country DESC", 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer rows.Close()
+	cols, _ := rows.Columns()
+	if len(cols) != 2 || cols[0] != "Country" || cols[1] != "Capital" {
+		t.Errorf("expected the columns Country and Capital but got %q", cols)
+	}
+	exp := [][2]string{{"Japan", "Tokyo"}, {"India", "New Delhi"}, {"Iceland", "Reykjavik"}}
+	i := 0
+	for rows.Next() {
+		var country string
+		var capital sql.NullString
+		if err := rows.Scan(&country, &capital); err != nil {
+			t.Error(err)
+			return
+		}
+		if i >= len(exp) || country != exp[i][0] || capital.String != exp[i][1] {
+			t.Errorf("unexpected row %d %s %s", i, country, capital.String)
+		}
+		i++
+	}
+	if rows.Err() != nil || i != len(exp) {
+		t.Errorf("expected %d rows but got %d %v", len(exp), i, rows.Err())
+	}
+}
+
+func TestQueryNull(t *testing.T) {
+	db := openDB(t)
+	var capital sql.NullString
+	err := db.QueryRow("SELECT Capital FROM countries.wsv WHERE Country = ?", "O'Land").Scan(&capital)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if capital.Valid {
+		t.Errorf("expected a null but got %q", capital.String)
+	}
+	var n int
+	err = db.QueryRow("SELECT Population FROM countries WHERE Capital IS NULL").Scan(&n)
+	if err != nil || n != 1 {
+		t.Errorf("expected a population of 1 but got %d %v", n, err)
+	}
+}
+
+func TestQueryFloat(t *testing.T) {
+	db := openDB(t)
+	for _, test := range []struct {
+		arg float64
+		exp string
+	}{
+		{1000000.0, "Japan"},
+		{0.00000015, "O'Land"},
+		{1e9, "India"},
+		{372000.5, "Japan"},
+	} {
+		var country string
+		err := db.QueryRow("SELECT Country FROM countries WHERE Population > ? ORDER BY Population", test.arg).Scan(&country)
+		if err != nil || country != test.exp {
+			t.Errorf("%v: expected %s but got %q %v", test.arg, test.exp, country, err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	db := openDB(t)
+	if _, err := db.Exec("DELETE FROM countries"); !errors.Is(err, sqldriver.ErrReadOnly) {
+		t.Errorf("expected the driver to be read only but got %v", err)
+	}
+	if _, err := db.Query("SELECT * FROM ../countries"); !errors.Is(err, sqldriver.ErrInvalidTable) {
+		t.Errorf("expected an invalid table but got %v", err)
+	}
+	if _, err := db.Query("SELECT Area FROM countries"); !errors.Is(err, reader.ErrFieldNotFound) {
+		t.Errorf("expected an unknown column but got %v", err)
+	}
+	if _, err := db.Query("SELECT * FROM countries WHERE Capital = ?", nil); !errors.Is(err, sqldriver.ErrUnsupportedValue) {
+		t.Errorf("expected a null argument to be unsupported but got %v", err)
+	}
+	if _, err := db.Query("SELECT * FROM countries WHERE Population > ?", math.Inf(1)); !errors.Is(err, sqldriver.ErrUnsupportedValue) {
+		t.Errorf("expected an infinite argument to be unsupported but got %v", err)
+	}
+	if _, err := db.Query("SELECT * FROM cities"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing table but got %v", err)
+	}
+	bad, _ := sql.Open("wsv", "path=/data")
+	if err := bad.Ping(); !errors.Is(err, sqldriver.ErrInvalidDSN) {
+		t.Errorf("expected an invalid data source name but got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Describes how a struct field maps onto a column, built from the `wsv` struct tag
+//
+//	`wsv:"Column Name"`                      maps the column "Column Name"
+//	`wsv:"Date of Birth,layout=Jan 02 2006"` maps the column and formats time.Time with the layout
+//	`wsv:"-"`                                skips the field
+//
+// fields without a tag map to the column with the same name as the field
+type TaggedField struct {
+	// index of the field in the struct
+	Index int
+	// name of the struct field
+	Name string
+	// name of the column
+	Column string
+	// layout used for time.Time values, defaults to time.RFC3339
+	Layout string
+}
+
+// Returns the exported fields of the struct type t in declaration order, omitting fields tagged with `wsv:"-"`
+func TaggedFields(t reflect.Type) []TaggedField {
+	fields := make([]TaggedField, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("wsv")
+		if tag == "-" {
+			continue
+		}
+		tf := TaggedField{Index: i, Name: f.Name, Column: f.Name, Layout: time.RFC3339}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name != "" {
+			tf.Column = name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			if layout, ok := strings.CutPrefix(opt, "layout="); ok {
+				tf.Layout = layout
+			}
+		}
+		fields = append(fields, tf)
+	}
+	return fields
+}
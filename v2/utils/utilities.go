@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -34,6 +35,34 @@ const (
 	CharIdeographicSpace        = 0x3000
 )
 
+// The whitespace code points that separate values in a WSV line, every one of them except the line feed
+var Whitespace = []rune{
+	CharCharacterTabulation,
+	CharLineTabulation,
+	CharFormFeed,
+	CharCarriageReturn,
+	CharSpace,
+	CharNextLine,
+	CharNoBreakSpace,
+	CharOghamSpaceMark,
+	CharEnQuad,
+	CharEmQuad,
+	CharEnSpace,
+	CharEmSpace,
+	CharThreePerEmSpace,
+	CharFourPerEmSpace,
+	CharSixPerEmSpace,
+	CharFigureSpace,
+	CharPunctuationSpace,
+	CharThinSpace,
+	CharHairSpace,
+	CharLineSeparator,
+	CharParagraphSeparator,
+	CharNarrowNoBreakSpace,
+	CharMediumMathematicalSpace,
+	CharIdeographicSpace,
+}
+
 var ptrSize int = 0
 
 func PtrSize() int {
@@ -53,10 +82,11 @@ func PtrSize() int {
 	return ptrSize
 }
 
+// Encodes the runes as UTF-8
 func RuneToBytes(rs []rune) []byte {
 	b := []byte{}
 	for _, r := range rs {
-		b = append(b, byte(r))
+		b = utf8.AppendRune(b, r)
 	}
 	return b
 }